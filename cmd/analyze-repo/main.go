@@ -1,30 +1,46 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 
-	"github.com/replyzer/analyze-repo/internal/analyzer"
+	"github.com/replyzer/analyze-repo/internal/cache"
+	"github.com/replyzer/analyze-repo/internal/config"
 	"github.com/replyzer/analyze-repo/internal/output"
-	"github.com/replyzer/analyze-repo/internal/types"
+	"github.com/replyzer/analyze-repo/pkg/replyzer"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	format    string
-	outputFile string
-	verbose   bool
-	component string
-	exclude   []string
-	version   string = "dev" // Set by build process
+	format      string
+	outputFile  string
+	verbose     bool
+	component   string
+	exclude     []string
+	trackedOnly bool
+	revision    string
+	disabled    []string
+	ruleFiles   []string
+	evidence    bool
+	precision   int
+	jobs        int
+	noCache     bool
+	maxFileSize string
+	statsMode   string
+	graphFormat string
+	version     string = "dev" // Set by build process
 )
 
 func main() {
 	var rootCmd = &cobra.Command{
-		Use:   "analyze-repo [path]",
+		Use:   "analyze-repo [path|archive]",
 		Short: "Analyze local repositories to identify development environment requirements",
 		Long: `A CLI tool that analyzes local repositories to detect languages, frameworks, 
 version requirements, and external dependencies across single projects and monorepos.`,
@@ -37,6 +53,16 @@ version requirements, and external dependencies across single projects and monor
 	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "Enable verbose logging")
 	rootCmd.Flags().StringVar(&component, "component", "", "Analyze specific component only")
 	rootCmd.Flags().StringSliceVar(&exclude, "exclude", []string{}, "Exclude patterns (glob format)")
+	rootCmd.Flags().BoolVar(&trackedOnly, "tracked-only", false, "Only analyze files tracked in the git index")
+	rootCmd.Flags().StringVar(&revision, "rev", "", "Analyze a git revision (branch, tag or commit) instead of the working tree")
+	rootCmd.Flags().StringSliceVar(&disabled, "disable-detector", []string{}, "Disable detectors by name (e.g. env-files)")
+	rootCmd.Flags().StringSliceVar(&ruleFiles, "rules", []string{}, "Additional detection rules files (YAML)")
+	rootCmd.Flags().IntVar(&jobs, "jobs", 0, "Number of components analyzed in parallel (default: number of CPUs)")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the analysis cache")
+	rootCmd.Flags().BoolVar(&evidence, "evidence", false, "Include the file, line and match behind every finding")
+	rootCmd.Flags().IntVar(&precision, "stats-precision", replyzer.DefaultStatsPrecision, "Decimal places of language_stats percentages")
+	rootCmd.Flags().StringVar(&statsMode, "stats-mode", replyzer.StatsModeBytes, "Metric language_stats and primary_language are based on (bytes|lines|files)")
+	rootCmd.Flags().StringVar(&maxFileSize, "max-file-size", "1M", "Leave larger files out of language_stats (e.g. 512K, 10M; 0 for no limit)")
 
 	// Add version command
 	var versionCmd = &cobra.Command{
@@ -48,13 +74,146 @@ version requirements, and external dependencies across single projects and monor
 	}
 	rootCmd.AddCommand(versionCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	var graphCmd = &cobra.Command{
+		Use:   "graph [path|archive]",
+		Short: "Render the dependency graph between components",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runGraph,
+	}
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Graph format (dot|mermaid)")
+	graphCmd.Flags().StringVar(&outputFile, "output", "", "Output file path (default: stdout)")
+	graphCmd.Flags().BoolVar(&verbose, "verbose", false, "Enable verbose logging")
+	graphCmd.Flags().StringVar(&component, "component", "", "Analyze specific component only")
+	graphCmd.Flags().StringSliceVar(&exclude, "exclude", []string{}, "Exclude patterns (glob format)")
+	graphCmd.Flags().BoolVar(&trackedOnly, "tracked-only", false, "Only analyze files tracked in the git index")
+	graphCmd.Flags().StringVar(&revision, "rev", "", "Analyze a git revision (branch, tag or commit) instead of the working tree")
+	graphCmd.Flags().StringSliceVar(&disabled, "disable-detector", []string{}, "Disable detectors by name (e.g. env-files)")
+	graphCmd.Flags().StringSliceVar(&ruleFiles, "rules", []string{}, "Additional detection rules files (YAML)")
+	graphCmd.Flags().IntVar(&jobs, "jobs", 0, "Number of components analyzed in parallel (default: number of CPUs)")
+	graphCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the analysis cache")
+	rootCmd.AddCommand(graphCmd)
+
+	var explainCmd = &cobra.Command{
+		Use:   "explain <component> <finding> [path|archive]",
+		Short: "Show where a framework, version, database, service or tool was found",
+		Args:  cobra.RangeArgs(2, 3),
+		RunE:  runExplain,
+	}
+	explainCmd.Flags().BoolVar(&verbose, "verbose", false, "Enable verbose logging")
+	explainCmd.Flags().StringSliceVar(&exclude, "exclude", []string{}, "Exclude patterns (glob format)")
+	explainCmd.Flags().BoolVar(&trackedOnly, "tracked-only", false, "Only analyze files tracked in the git index")
+	explainCmd.Flags().StringVar(&revision, "rev", "", "Analyze a git revision (branch, tag or commit) instead of the working tree")
+	explainCmd.Flags().StringSliceVar(&disabled, "disable-detector", []string{}, "Disable detectors by name (e.g. env-files)")
+	explainCmd.Flags().StringSliceVar(&ruleFiles, "rules", []string{}, "Additional detection rules files (YAML)")
+	explainCmd.Flags().IntVar(&jobs, "jobs", 0, "Number of components analyzed in parallel (default: number of CPUs)")
+	explainCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the analysis cache")
+	rootCmd.AddCommand(explainCmd)
+
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the analysis cache",
+	}
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clean",
+		Short: "Remove the cached results of all repositories",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheClean,
+	})
+	rootCmd.AddCommand(cacheCmd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func runAnalysis(cmd *cobra.Command, args []string) error {
+	result, cfg, err := analyze(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	outputFormat := cfg.Format
+	if outputFormat == "" || cmd.Flags().Changed("format") {
+		outputFormat = format
+	}
+
+	var outputData []byte
+	switch outputFormat {
+	case "json":
+		outputData, err = json.MarshalIndent(result, "", "  ")
+	case "yaml":
+		outputData, err = yaml.Marshal(result)
+	default:
+		return fmt.Errorf("unsupported format: %s", outputFormat)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	if outputFile != "" {
+		return output.WriteToFile(outputFile, outputData)
+	}
+
+	fmt.Print(string(outputData))
+	return nil
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	result, _, err := analyze(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	outputData, err := output.RenderGraph(result, graphFormat)
+	if err != nil {
+		return err
+	}
+
+	if outputFile != "" {
+		return output.WriteToFile(outputFile, outputData)
+	}
+
+	fmt.Print(string(outputData))
+	return nil
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	evidence = true
+	result, _, err := analyze(cmd, args[2:])
+	if err != nil {
+		return err
+	}
+
+	outputData, err := output.RenderExplanation(result, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(outputData))
+	return nil
+}
+
+func runCacheClean(cmd *cobra.Command, args []string) error {
+	dir, err := cache.Dir()
+	if err != nil {
+		return fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+
+	removed, err := cache.Clean(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d cached repositories from %s\n", removed, dir)
+	return nil
+}
+
+func analyze(cmd *cobra.Command, args []string) (*replyzer.Result, *config.Config, error) {
 	repoPath := "."
 	if len(args) > 0 {
 		repoPath = args[0]
@@ -62,44 +221,104 @@ func runAnalysis(cmd *cobra.Command, args []string) error {
 
 	absPath, err := filepath.Abs(repoPath)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return nil, nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	cfg, err := loadConfig(cmd, absPath)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	options := &types.AnalysisOptions{
-		Format:    format,
-		Output:    outputFile,
-		Verbose:   verbose,
-		Component: component,
-		Exclude:   exclude,
+	maxSize, err := parseSize(maxFileSize)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	options := []replyzer.Option{
+		replyzer.WithComponent(component),
+		replyzer.WithExclude(cfg.Exclude...),
+		replyzer.WithRevision(revision),
+		replyzer.WithDisabledDetectors(cfg.DisabledDetectors...),
+		replyzer.WithRules(cfg.Rules...),
+		replyzer.WithOverrides(cfg.Components...),
+		replyzer.WithStatsPrecision(precision),
+		replyzer.WithJobs(jobs),
+		replyzer.WithMaxFileSize(maxSize),
+		replyzer.WithStatsMode(statsMode),
+	}
+	if trackedOnly {
+		options = append(options, replyzer.WithTrackedOnly())
+	}
+	if !noCache {
+		if dir, err := cache.Dir(); err == nil {
+			options = append(options, replyzer.WithCache(dir))
+		}
+	}
+	if evidence {
+		options = append(options, replyzer.WithEvidence())
+	}
 	if verbose {
+		options = append(options, replyzer.WithVerbose())
+		for _, file := range cfg.Files {
+			fmt.Fprintf(os.Stderr, "Using config file: %s\n", file)
+		}
 		fmt.Fprintf(os.Stderr, "Analyzing repository at: %s\n", absPath)
 	}
 
-	result, err := analyzer.AnalyzeRepository(absPath, options)
+	result, err := replyzer.New(options...).Analyze(cmd.Context(), absPath)
 	if err != nil {
-		return fmt.Errorf("analysis failed: %w", err)
+		return nil, nil, fmt.Errorf("analysis failed: %w", err)
 	}
 
-	var outputData []byte
-	switch format {
-	case "json":
-		outputData, err = json.MarshalIndent(result, "", "  ")
-	case "yaml":
-		outputData, err = yaml.Marshal(result)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	return result, cfg, nil
+}
+
+// loadConfig layers the user config, the repository's .replyzer.yaml, the
+// REPLYZER_* environment variables and the flags set on the command line,
+// each taking precedence over the previous ones.
+func loadConfig(cmd *cobra.Command, repoPath string) (*config.Config, error) {
+	repoDir := ""
+	if info, err := os.Stat(repoPath); err == nil && info.IsDir() {
+		repoDir = repoPath
 	}
 
+	cfg, err := config.Load(repoDir)
 	if err != nil {
-		return fmt.Errorf("failed to marshal output: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
+	cfg.Merge(config.FromEnv(os.Getenv))
 
-	if outputFile != "" {
-		return output.WriteToFile(outputFile, outputData)
+	flags := cmd.Flags()
+	if flags.Changed("exclude") {
+		cfg.Exclude = exclude
+	}
+	if flags.Changed("rules") {
+		cfg.Rules = ruleFiles
+	}
+	if flags.Changed("disable-detector") {
+		cfg.DisabledDetectors = disabled
 	}
 
-	fmt.Print(string(outputData))
-	return nil
-}
\ No newline at end of file
+	return cfg, nil
+}
+
+// parseSize parses a number of bytes with an optional K, M or G suffix for
+// powers of 1024, e.g. "512K" or "10MB".
+func parseSize(size string) (int64, error) {
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+
+	multiplier := int64(1)
+	for i, suffix := range []string{"K", "M", "G"} {
+		if strings.HasSuffix(number, suffix) {
+			number = strings.TrimSuffix(number, suffix)
+			multiplier = 1 << (10 * (i + 1))
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return n * multiplier, nil
+}
@@ -35,4 +35,27 @@ func TestVersionInfo(t *testing.T) {
 	
 	// Restore original version
 	version = originalVersion
-}
\ No newline at end of file
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":     0,
+		"1024":  1024,
+		"512K":  512 << 10,
+		"10MB":  10 << 20,
+		"2g":    2 << 30,
+		" 1M ": 1 << 20,
+	}
+	for size, want := range tests {
+		got, err := parseSize(size)
+		if err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", size, got, err, want)
+		}
+	}
+
+	for _, size := range []string{"", "MB", "-1", "1T", "1.5M"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("Expected an error for %q", size)
+		}
+	}
+}
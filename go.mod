@@ -3,6 +3,7 @@ module github.com/replyzer/analyze-repo
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/go-enry/go-enry/v2 v2.9.2
 	github.com/spf13/cobra v1.9.1
 	gopkg.in/yaml.v3 v3.0.1
@@ -1,37 +1,146 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/replyzer/analyze-repo/internal/cache"
+	"github.com/replyzer/analyze-repo/internal/rules"
 	"github.com/replyzer/analyze-repo/internal/types"
 )
 
-func AnalyzeRepository(repoPath string, options *types.AnalysisOptions) (*types.AnalysisResult, error) {
-	if options.Verbose {
-		fmt.Printf("Starting analysis of repository: %s\n", repoPath)
+func AnalyzeRepository(ctx context.Context, repoPath string, options *types.AnalysisOptions) (*types.AnalysisResult, error) {
+	verboseLogger(options).Printf("Starting analysis of repository: %s", repoPath)
+
+	repo := newRepository(repoPath)
+	defer repo.close()
+
+	switch {
+	case repo.isArchive():
+		if options.Revision != "" || options.TrackedOnly {
+			return nil, fmt.Errorf("revisions and tracked-only analysis are not supported for archives")
+		}
+		if err := repo.useArchive(); err != nil {
+			return nil, err
+		}
+	case options.Revision != "":
+		if err := repo.useRevision(options.Revision); err != nil {
+			return nil, err
+		}
+	case options.TrackedOnly:
+		if err := repo.restrictToTracked(); err != nil {
+			return nil, err
+		}
+	}
+	if !repo.isArchive() && options.Revision == "" {
+		repo.addParentIgnores(nil)
 	}
 
-	structure, err := DiscoverProjectStructure(repoPath)
+	// Revisions and archives are immutable and have no meaningful
+	// modification times, so only working trees are cached.
+	if options.CacheDir != "" && !repo.isArchive() && options.Revision == "" {
+		root, err := filepath.Abs(repoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		repo.cache = cache.Open(options.CacheDir, root)
+	}
+
+	return repo.analyze(ctx, options)
+}
+
+func verboseLogger(options *types.AnalysisOptions) *log.Logger {
+	switch {
+	case !options.Verbose:
+		return log.New(io.Discard, "", 0)
+	case options.LogOutput != nil:
+		return log.New(options.LogOutput, "", 0)
+	}
+	return log.New(os.Stderr, "", 0)
+}
+
+// AnalyzeFS analyzes a repository in any file system. name is reported as
+// the repository path and name.
+func AnalyzeFS(ctx context.Context, fsys fs.FS, name string, options *types.AnalysisOptions) (*types.AnalysisResult, error) {
+	if options.Revision != "" || options.TrackedOnly {
+		return nil, fmt.Errorf("revisions and tracked-only analysis require a repository path")
+	}
+
+	return newRepositoryFS(name, fsys).analyze(ctx, options)
+}
+
+func (r *repository) analyze(ctx context.Context, options *types.AnalysisOptions) (*types.AnalysisResult, error) {
+	r.ctx = ctx
+	r.log = verboseLogger(options)
+
+	detectors, err := enabledDetectors(options.DisabledDetectors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to discover project structure: %w", err)
+		return nil, err
 	}
+	r.detectors = detectors
 
-	if options.Verbose {
-		fmt.Printf("Discovered %d components\n", len(structure.Components))
+	if options.Jobs < 0 {
+		return nil, fmt.Errorf("invalid number of jobs %d: must not be negative", options.Jobs)
+	}
+	precision := DefaultStatsPrecision
+	if options.StatsPrecision != nil {
+		precision = *options.StatsPrecision
+	}
+	if precision < 0 {
+		return nil, fmt.Errorf("invalid stats precision %d: must not be negative", precision)
 	}
+	switch options.StatsMode {
+	case "":
+		r.statsMode = StatsModeBytes
+	case StatsModeBytes, StatsModeLines, StatsModeFiles:
+		r.statsMode = options.StatsMode
+	default:
+		return nil, fmt.Errorf("invalid stats mode %q: must be %s, %s or %s", options.StatsMode, StatsModeBytes, StatsModeLines, StatsModeFiles)
+	}
+	if options.MaxFileSize < 0 {
+		return nil, fmt.Errorf("invalid max file size %d: must not be negative", options.MaxFileSize)
+	}
+	r.maxFileSize = options.MaxFileSize
 
-	repoName := filepath.Base(repoPath)
+	ruleSet, err := rules.Load(options.RuleFiles...)
+	if err != nil {
+		return nil, err
+	}
+	r.rules = ruleSet
+	r.overrides = options.Overrides
+
+	structure, err := r.discover()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover project structure: %w", err)
+	}
+
+	r.log.Printf("Discovered %d components", len(structure.Components))
+
+	repoName := filepath.Base(r.root)
 	result := &types.AnalysisResult{
 		Repository: types.Repository{
-			Type: structure.Type,
-			Path: repoPath,
-			Name: repoName,
+			Type:       structure.Type,
+			Path:       r.root,
+			Name:       repoName,
+			Revision:   options.Revision,
+			Commit:     r.commit,
+			Workspaces: structure.Workspaces,
 		},
 		Components: make([]types.Component, 0),
 	}
 
+	var selected []types.ComponentInfo
 	for _, compInfo := range structure.Components {
 		if options.Component != "" && compInfo.Name != options.Component {
 			continue
@@ -41,69 +150,232 @@ func AnalyzeRepository(repoPath string, options *types.AnalysisOptions) (*types.
 			continue
 		}
 
-		if options.Verbose {
-			fmt.Printf("Analyzing component: %s\n", compInfo.Name)
-		}
+		selected = append(selected, compInfo)
+	}
 
-		component, err := AnalyzeComponent(compInfo)
-		if err != nil {
-			if options.Verbose {
-				fmt.Printf("Warning: failed to analyze component %s: %v\n", compInfo.Name, err)
-			}
+	components, errs := r.analyzeComponents(selected, options.Jobs)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err := r.cache.Save(); err != nil {
+		r.log.Printf("Warning: %v", err)
+	}
+	if r.cache != nil {
+		hits, misses := r.cache.Stats()
+		r.log.Printf("Reused %d of %d cached file results", hits, hits+misses)
+	}
+	if summary := r.skipped.summary(); summary != "" {
+		r.log.Printf("Skipped files in language stats: %s", summary)
+	}
+
+	var analyzed []types.ComponentInfo
+	for i, compInfo := range selected {
+		if errs[i] != nil {
+			r.log.Printf("Warning: failed to analyze component %s: %v", compInfo.Name, errs[i])
 			continue
 		}
 
+		component := components[i]
+		if !options.Evidence {
+			component.Evidence = nil
+		}
+		normalizeComponent(component, precision)
+
 		result.Components = append(result.Components, *component)
+		analyzed = append(analyzed, compInfo)
+	}
+
+	deps, err := r.dependencyGraph(analyzed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dependency graph: %w", err)
+	}
+	result.Dependencies = deps
+
+	if len(analyzed) > 1 {
+		nodes := make([]string, 0, len(analyzed))
+		for _, compInfo := range analyzed {
+			nodes = append(nodes, workspacePath(compInfo.RelativePath))
+		}
+
+		order, cyclic := BuildOrder(nodes, deps)
+		if len(cyclic) > 0 {
+			r.log.Printf("Warning: dependency cycle between components: %s", strings.Join(cyclic, ", "))
+		}
+		result.BuildOrder = append(order, cyclic...)
 	}
 
 	return result, nil
 }
 
-func AnalyzeComponent(compInfo types.ComponentInfo) (*types.Component, error) {
-	langStats, err := GetLanguageStats(compInfo.Path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get language stats: %w", err)
+// A component that fails or panics does not affect the others. Components
+// not yet started when the context is cancelled are skipped.
+func (r *repository) analyzeComponents(components []types.ComponentInfo, jobs int) ([]*types.Component, []error) {
+	if jobs == 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	if jobs > len(components) {
+		jobs = len(components)
 	}
 
-	primaryLang := GetPrimaryLanguage(langStats)
+	results := make([]*types.Component, len(components))
+	errs := make([]error, len(components))
 
-	framework, err := DetectFrameworks(compInfo.Path, primaryLang)
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect framework: %w", err)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < jobs; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r.log.Printf("Analyzing component: %s", components[i].Name)
+				results[i], errs[i] = r.analyzeComponentIsolated(components[i])
+			}
+		}()
 	}
 
-	versionReqs, err := ExtractVersionRequirements(compInfo.Path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract version requirements: %w", err)
+	for i := range components {
+		if r.ctx.Err() != nil {
+			errs[i] = r.ctx.Err()
+			continue
+		}
+		indexes <- i
 	}
+	close(indexes)
+	wg.Wait()
+
+	return results, errs
+}
+
+func (r *repository) analyzeComponentIsolated(compInfo types.ComponentInfo) (component *types.Component, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			component, err = nil, fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return r.analyzeComponent(compInfo, componentDir(compInfo))
+}
 
-	externalDeps, err := DetectExternalDependencies(compInfo.Path)
+func AnalyzeComponent(fsys fs.FS, compInfo types.ComponentInfo) (*types.Component, error) {
+	return newRepositoryFS("", fsys).analyzeComponent(compInfo, componentDir(compInfo))
+}
+
+func (r *repository) analyzeComponent(compInfo types.ComponentInfo, dir string) (*types.Component, error) {
+	langCounts, err := r.languageCounts(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect external dependencies: %w", err)
+		return nil, fmt.Errorf("failed to get language stats: %w", err)
 	}
+	langStats := languageShares(langCounts, r.statsMode)
 
-	devTools, err := DetectDevelopmentTools(compInfo.Path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect development tools: %w", err)
+	component := &types.Component{
+		Name:                compInfo.Name,
+		Path:                compInfo.RelativePath,
+		PrimaryLanguage:     GetPrimaryLanguage(langStats),
+		LanguageStats:       langStats,
+		LanguageCounts:      langCounts,
+		VersionRequirements: make(map[string]string),
+		ExternalDependencies: types.ExternalDependencies{
+			Databases: make([]string, 0),
+			Services:  make([]string, 0),
+		},
+		Workspace: compInfo.Workspace,
 	}
 
-	componentType := inferComponentType(primaryLang, framework, compInfo.ConfigFiles)
+	detectors := r.detectors
+	if detectors == nil {
+		detectors = Detectors()
+	}
 
-	component := &types.Component{
-		Name:                 compInfo.Name,
-		Path:                 compInfo.RelativePath,
-		Type:                 componentType,
-		PrimaryLanguage:      primaryLang,
-		LanguageStats:        langStats,
-		Framework:            framework,
-		VersionRequirements:  versionReqs,
-		ExternalDependencies: *externalDeps,
-		DevelopmentTools:     devTools,
+	target := &Target{Context: r.ctx, FS: r.fsys, Dir: dir, Info: compInfo, repo: r}
+	for _, detector := range detectors {
+		if err := detector.Detect(target, component); err != nil {
+			return nil, fmt.Errorf("detector %s failed: %w", detector.Name(), err)
+		}
+	}
+
+	override, pattern := r.override(dir)
+	if override.Framework != "" {
+		component.Framework = override.Framework
+
+		candidates := []types.FrameworkCandidate{{Name: override.Framework, Confidence: 1}}
+		for _, candidate := range component.FrameworkCandidates {
+			if candidate.Name != override.Framework {
+				candidates = append(candidates, candidate)
+			}
+		}
+		component.FrameworkCandidates = candidates
+
+		evidence := component.Evidence[:0]
+		for _, e := range component.Evidence {
+			if e.Kind != evidenceFramework {
+				evidence = append(evidence, e)
+			}
+		}
+		component.Evidence = append(evidence, types.Evidence{
+			Kind:  evidenceFramework,
+			Name:  override.Framework,
+			Match: "component override " + pattern,
+		})
+	}
+
+	component.Type = inferComponentType(component.PrimaryLanguage, component.Framework, compInfo.ConfigFiles)
+	if compInfo.WorkspaceRoot {
+		component.Type = "workspace"
+	}
+	if override.Type != "" {
+		component.Type = override.Type
 	}
 
 	return component, nil
 }
 
+// Later overrides take precedence.
+func (r *repository) override(dir string) (types.ComponentOverride, string) {
+	var combined types.ComponentOverride
+	var frameworkPattern string
+	for _, override := range r.overrides {
+		pattern := path.Clean(filepath.ToSlash(override.Path))
+		if matched, _ := path.Match(pattern, dir); !matched {
+			continue
+		}
+		if override.Type != "" {
+			combined.Type = override.Type
+		}
+		if override.Framework != "" {
+			combined.Framework = override.Framework
+			frameworkPattern = override.Path
+		}
+	}
+	return combined, frameworkPattern
+}
+
+// DefaultMaxFileSize is the default size limit in bytes for language stats.
+const DefaultMaxFileSize = 1 << 20
+
+// DefaultStatsPrecision is the default number of decimal places of language
+// stats.
+const DefaultStatsPrecision = 2
+
+// normalizeComponent keeps the output independent of detection order and
+// floating point noise.
+func normalizeComponent(component *types.Component, precision int) {
+	scale := math.Pow(10, float64(precision))
+	for language, percentage := range component.LanguageStats {
+		component.LanguageStats[language] = math.Round(percentage*scale) / scale
+	}
+
+	sort.Strings(component.ExternalDependencies.Databases)
+	sort.Strings(component.ExternalDependencies.Services)
+	sort.Strings(component.DevelopmentTools)
+}
+
+func componentDir(compInfo types.ComponentInfo) string {
+	if compInfo.RelativePath == "" {
+		return "."
+	}
+	return filepath.ToSlash(compInfo.RelativePath)
+}
+
 func inferComponentType(primaryLang, framework string, configFiles []string) string {
 	langLower := strings.ToLower(primaryLang)
 	frameworkLower := strings.ToLower(framework)
@@ -133,11 +405,13 @@ func inferComponentType(primaryLang, framework string, configFiles []string) str
 			return "web-application"
 		}
 	case "python":
-		if hasConfigFile(configFiles, "requirements.txt") || hasConfigFile(configFiles, "pyproject.toml") {
-			return "api-service"
+		for _, file := range []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py", "environment.yml", "environment.yaml"} {
+			if hasConfigFile(configFiles, file) {
+				return "api-service"
+			}
 		}
 	case "java":
-		if hasConfigFile(configFiles, "pom.xml") || hasConfigFile(configFiles, "build.gradle") {
+		if hasConfigFile(configFiles, "pom.xml") || hasConfigFile(configFiles, "build.gradle") || hasConfigFile(configFiles, "build.gradle.kts") {
 			return "api-service"
 		}
 	case "go":
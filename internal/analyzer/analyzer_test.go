@@ -1,9 +1,23 @@
 package analyzer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/replyzer/analyze-repo/internal/types"
+	"gopkg.in/yaml.v3"
 )
 
 func TestInferComponentType(t *testing.T) {
@@ -182,4 +196,333 @@ func TestAnalysisOptions(t *testing.T) {
 	if len(options.Exclude) != 2 {
 		t.Errorf("Expected 2 exclude patterns, got %v", len(options.Exclude))
 	}
-}
\ No newline at end of file
+}
+
+func TestAnalyzeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"web/package.json": &fstest.MapFile{Data: []byte(`{
+			"name": "web",
+			"engines": {"node": ">=18"},
+			"dependencies": {"react": "^18.0.0"},
+			"devDependencies": {"eslint": "^8.0.0", "typescript": "^5.0.0"}
+		}`)},
+		"web/src/App.js":           &fstest.MapFile{Data: []byte(strings.Repeat("export const App = () => null;\n", 20))},
+		"api/requirements.txt":     &fstest.MapFile{Data: []byte("fastapi==0.110.0\nuvicorn\n")},
+		"api/.python-version":      &fstest.MapFile{Data: []byte("3.12\n")},
+		"api/main.py":              &fstest.MapFile{Data: []byte(strings.Repeat("print('api')\n", 20))},
+		"api/.env":                 &fstest.MapFile{Data: []byte("REDIS_URL=redis://localhost:6379\n")},
+		"api/docker-compose.yml":   &fstest.MapFile{Data: []byte("services:\n  db:\n    image: postgres:16\n")},
+		"api/node_modules/x/x.js":  &fstest.MapFile{Data: []byte("module.exports = {};\n")},
+		"api/.venv/lib/site.py":    &fstest.MapFile{Data: []byte("import os\n")},
+		"api/__pycache__/main.pyc": &fstest.MapFile{Data: []byte{0, 1, 2}},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "shop", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	if result.Repository.Name != "shop" || result.Repository.Type != "monorepo" {
+		t.Errorf("Unexpected repository: %+v", result.Repository)
+	}
+	if len(result.Components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(result.Components))
+	}
+
+	for _, comp := range result.Components {
+		switch comp.Name {
+		case "web":
+			if comp.Framework != "React" || comp.VersionRequirements["node"] != ">=18" {
+				t.Errorf("Unexpected web component: %+v", comp)
+			}
+			if !reflect.DeepEqual(comp.DevelopmentTools, []string{"ESLint", "TypeScript"}) {
+				t.Errorf("Unexpected development tools: %v", comp.DevelopmentTools)
+			}
+		case "api":
+			if comp.Framework != "FastAPI" || comp.VersionRequirements["python"] != "3.12" {
+				t.Errorf("Unexpected api component: %+v", comp)
+			}
+			if _, exists := comp.LanguageStats["JavaScript"]; exists || comp.PrimaryLanguage != "Python" {
+				t.Errorf("Expected ignored directories to be skipped, got %v", comp.LanguageStats)
+			}
+			if !reflect.DeepEqual(comp.ExternalDependencies.Databases, []string{"PostgreSQL"}) ||
+				!reflect.DeepEqual(comp.ExternalDependencies.Services, []string{"Redis"}) {
+				t.Errorf("Unexpected external dependencies: %+v", comp.ExternalDependencies)
+			}
+		default:
+			t.Errorf("Unexpected component %s", comp.Name)
+		}
+	}
+
+	if _, err := AnalyzeFS(context.Background(), fsys, "shop", &types.AnalysisOptions{TrackedOnly: true}); err == nil {
+		t.Error("Expected an error for tracked-only analysis of a file system")
+	}
+}
+
+func TestComponentOverrides(t *testing.T) {
+	fsys := fstest.MapFS{
+		"services/billing/go.mod":  &fstest.MapFile{Data: []byte("module example.com/billing\n\ngo 1.22\n")},
+		"services/billing/main.go": &fstest.MapFile{Data: []byte(strings.Repeat("package main\n", 20))},
+		"tools/gen/go.mod":         &fstest.MapFile{Data: []byte("module example.com/gen\n\ngo 1.22\n")},
+		"tools/gen/main.go":        &fstest.MapFile{Data: []byte(strings.Repeat("package main\n", 20))},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "repo", &types.AnalysisOptions{
+		Overrides: []types.ComponentOverride{
+			{Path: "services/*", Framework: "Gin"},
+			{Path: "./tools/gen", Type: "cli-tool"},
+			{Path: "tools/*", Type: "library"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	for _, comp := range result.Components {
+		switch comp.Name {
+		case "billing":
+			if comp.Framework != "Gin" || comp.Type != "api-service" {
+				t.Errorf("Expected the framework override to drive the type, got %s (%s)", comp.Framework, comp.Type)
+			}
+		case "gen":
+			if comp.Type != "library" {
+				t.Errorf("Expected the later override to win, got %s", comp.Type)
+			}
+		default:
+			t.Errorf("Unexpected component %s", comp.Name)
+		}
+	}
+}
+
+func TestAnalysisIsDeterministic(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"package.json":                   "{\"name\": \"shop\", \"workspaces\": [\"packages/*\"]}",
+		"packages/web/package.json":      `{"dependencies": {"next": "14.0.0", "react": "18.2.0"}, "devDependencies": {"typescript": "5.0.0", "eslint": "8.0.0", "prettier": "3.0.0"}}`,
+		"packages/web/index.ts":          strings.Repeat("export const web = 1;\n", 20),
+		"packages/web/styles.css":        strings.Repeat("body { margin: 0; }\n", 7),
+		"packages/ui/package.json":       `{"dependencies": {"react": "18.2.0", "vue": "3.0.0"}}`,
+		"packages/ui/index.js":           strings.Repeat("export const ui = 1;\n", 20),
+		"services/api/go.mod":            "module example.com/api\n\ngo 1.22\n\nrequire github.com/gin-gonic/gin v1.9.0\n",
+		"services/api/main.go":           strings.Repeat("package main\n", 30),
+		"services/api/.env":              "REDIS_URL=redis://cache\nMONGO_URL=mongodb://db\nDATABASE_URL=postgres://db\nAMQP_URL=amqp://mq\n",
+		"services/api/compose.yaml":      "services:\n  b:\n    image: mysql:8\n  a:\n    image: postgres:16\n  c:\n    image: acme/queue:1\n  d:\n    image: acme/auth:2\n",
+		"services/worker/pyproject.toml": "[project]\nrequires-python = \">=3.11\"\n",
+		"services/worker/worker.py":      strings.Repeat("print('work')\n", 13),
+	})
+
+	var first []byte
+	for run := 0; run < 20; run++ {
+		result, err := AnalyzeFS(context.Background(), fsys, "shop", &types.AnalysisOptions{Evidence: true})
+		if err != nil {
+			t.Fatalf("AnalyzeFS() error = %v", err)
+		}
+
+		jsonData, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		yamlData, err := yaml.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := append(jsonData, yamlData...)
+
+		if run == 0 {
+			first = output
+			continue
+		}
+		if !bytes.Equal(output, first) {
+			t.Fatalf("Run %d produced different output:\n%s\nfirst run:\n%s", run, output, first)
+		}
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "shop", &types.AnalysisOptions{StatsPrecision: intPtr(1)})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	var paths []string
+	for _, comp := range result.Components {
+		paths = append(paths, comp.Path)
+		for language, percentage := range comp.LanguageStats {
+			if percentage != math.Round(percentage*10)/10 {
+				t.Errorf("Expected %s stats of %s rounded to one decimal, got %v", language, comp.Name, percentage)
+			}
+		}
+		if comp.Name == "api" {
+			deps := comp.ExternalDependencies
+			if !sort.StringsAreSorted(deps.Databases) || !sort.StringsAreSorted(deps.Services) || len(deps.Services) < 2 {
+				t.Errorf("Expected sorted external dependencies, got %+v", deps)
+			}
+		}
+	}
+	if !sort.StringsAreSorted(paths) {
+		t.Errorf("Expected components sorted by path, got %v", paths)
+	}
+
+	if _, err := AnalyzeFS(context.Background(), fsys, "shop", &types.AnalysisOptions{StatsPrecision: intPtr(-1)}); err == nil {
+		t.Error("Expected an error for a negative stats precision")
+	}
+}
+
+func TestDefaultStatsPrecision(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"package.json": `{"name": "web"}`,
+		"index.ts":     strings.Repeat("export const web = 1;\n", 20),
+		"styles.css":   strings.Repeat("body { margin: 0; }\n", 7),
+	})
+
+	// Options that leave StatsPrecision unset get DefaultStatsPrecision,
+	// not whole percentages.
+	result, err := AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if got, want := result.Components[0].LanguageStats["TypeScript"], 73.95; got != want {
+		t.Errorf("Expected TypeScript stats of %v, got %v", want, got)
+	}
+
+	result, err = AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{StatsPrecision: intPtr(0)})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if got, want := result.Components[0].LanguageStats["TypeScript"], 74.0; got != want {
+		t.Errorf("Expected TypeScript stats of %v, got %v", want, got)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// failingDetector only fails for directories no other test uses, so it can
+// stay registered.
+type failingDetector struct{}
+
+var registerFailing sync.Once
+
+func (failingDetector) Name() string { return "test-failing" }
+
+func (failingDetector) Detect(target *Target, component *types.Component) error {
+	switch target.Dir {
+	case "services/broken":
+		return errors.New("unreadable manifest")
+	case "services/panics":
+		panic("detector bug")
+	}
+	return nil
+}
+
+func TestAnalyzeComponentsIsolatesFailures(t *testing.T) {
+	files := map[string]string{}
+	for _, name := range []string{"api", "broken", "panics", "worker"} {
+		files["services/"+name+"/go.mod"] = "module example.com/" + name + "\n\ngo 1.22\n"
+		files["services/"+name+"/main.go"] = strings.Repeat("package main\n", 10)
+	}
+
+	registerFailing.Do(func() { RegisterDetector(failingDetector{}) })
+
+	for _, jobs := range []int{1, 4} {
+		result, err := AnalyzeFS(context.Background(), mapFS(files), "repo", &types.AnalysisOptions{Jobs: jobs})
+		if err != nil {
+			t.Fatalf("analyze() with %d jobs error = %v", jobs, err)
+		}
+
+		var names []string
+		for _, comp := range result.Components {
+			names = append(names, comp.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"api", "worker"}) {
+			t.Errorf("Expected failing components to be skipped with %d jobs, got %v", jobs, names)
+		}
+	}
+}
+
+func TestAnalyzeJobsProduceIdenticalOutput(t *testing.T) {
+	root := t.TempDir()
+	writeMonorepo(t, root, 40)
+
+	var outputs [][]byte
+	for _, jobs := range []int{1, 3, 16} {
+		result, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{Jobs: jobs, Evidence: true})
+		if err != nil {
+			t.Fatalf("AnalyzeRepository() with %d jobs error = %v", jobs, err)
+		}
+		if len(result.Components) != 41 {
+			t.Fatalf("Expected 41 components, got %d", len(result.Components))
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+
+	for i := 1; i < len(outputs); i++ {
+		if !bytes.Equal(outputs[i], outputs[0]) {
+			t.Errorf("Output with jobs run %d differs from sequential output", i)
+		}
+	}
+
+	if _, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{Jobs: -1}); err == nil {
+		t.Error("Expected an error for a negative number of jobs")
+	}
+}
+
+// writeMonorepo creates an npm workspace with n packages alternating between
+// React frontends, Express services and Go services.
+func writeMonorepo(tb testing.TB, root string, n int) {
+	tb.Helper()
+
+	files := map[string]string{
+		"package.json": `{"name": "monorepo", "private": true, "workspaces": ["packages/*"]}`,
+	}
+	for i := 0; i < n; i++ {
+		dir := fmt.Sprintf("packages/pkg-%03d", i)
+		switch i % 3 {
+		case 0:
+			files[dir+"/package.json"] = fmt.Sprintf(`{"name": "pkg-%03d", "engines": {"node": ">=18"}, "dependencies": {"react": "18.2.0"}, "devDependencies": {"eslint": "8.0.0", "jest": "29.0.0"}}`, i)
+			for j := 0; j < 20; j++ {
+				files[fmt.Sprintf("%s/src/Component%02d.jsx", dir, j)] = "import React from 'react';\n" + strings.Repeat("export const value = <div>component</div>;\n", 40)
+			}
+		case 1:
+			files[dir+"/package.json"] = fmt.Sprintf(`{"name": "pkg-%03d", "dependencies": {"express": "4.19.0", "pkg-%03d": "*"}}`, i, i-1)
+			files[dir+"/docker-compose.yml"] = "services:\n  db:\n    image: postgres:16\n  cache:\n    image: redis:7\n"
+			files[dir+"/.env"] = "DATABASE_URL=postgres://db/app\n"
+			for j := 0; j < 20; j++ {
+				files[fmt.Sprintf("%s/routes/route%02d.js", dir, j)] = "const express = require('express');\n" + strings.Repeat("module.exports.handler = (req, res) => res.send('ok');\n", 40)
+			}
+		default:
+			files[dir+"/go.mod"] = fmt.Sprintf("module example.com/pkg-%03d\n\ngo 1.22\n\nrequire github.com/gin-gonic/gin v1.9.0\n", i)
+			for j := 0; j < 20; j++ {
+				files[fmt.Sprintf("%s/handler%02d.go", dir, j)] = "package main\n\nimport \"github.com/gin-gonic/gin\"\n\n" + strings.Repeat("var _ = gin.New\n", 40)
+			}
+		}
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeMonorepo(b *testing.B) {
+	root := b.TempDir()
+	writeMonorepo(b, root, 300)
+
+	for _, jobs := range []int{1, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{Jobs: jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"io/fs"
+
+	"github.com/replyzer/analyze-repo/internal/cache"
+)
+
+const (
+	cacheLanguage     = "language"
+	cacheDependencies = "dependencies"
+	cacheIncludes     = "includes"
+)
+
+// fileContent is cached before .gitattributes overrides are applied.
+type fileContent struct {
+	Language  string     `json:"language,omitempty"`
+	Bytes     int        `json:"bytes"`
+	Generated bool       `json:"generated,omitempty"`
+	Lines     lineCounts `json:"lines"`
+	CountedAs string     `json:"counted_as,omitempty"` // comment syntax of Lines
+}
+
+// readCached loads the cached result of kind for name into v, or calls
+// compute to fill it.
+func (r *repository) readCached(name, kind string, v any, compute func(content []byte)) error {
+	if r.cache == nil {
+		content, err := fs.ReadFile(r.fsys, name)
+		if err != nil {
+			return err
+		}
+		compute(content)
+		return nil
+	}
+
+	info, err := fs.Stat(r.fsys, name)
+	if err != nil {
+		return err
+	}
+	if r.cache.Get(name, info, "", kind, v) {
+		return nil
+	}
+
+	content, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return err
+	}
+
+	hash := cache.Hash(content)
+	if !r.cache.Get(name, info, hash, kind, v) {
+		compute(content)
+		r.cache.Put(name, info, hash, kind, v)
+	}
+	return nil
+}
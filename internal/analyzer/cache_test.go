@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/cache"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestAnalyzeWithCache(t *testing.T) {
+	root, cacheDir := t.TempDir(), t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"express": "4.0.0", "pg": "8.0.0"}}`,
+		"server.js":    "const express = require('express');\n",
+		"worker.py":    "import os\n",
+	})
+
+	analyze := func() (*types.AnalysisResult, int, int) {
+		t.Helper()
+		repo := newRepository(root)
+		repo.cache = cache.Open(cacheDir, root)
+		result, err := repo.analyze(context.Background(), &types.AnalysisOptions{Evidence: true})
+		if err != nil {
+			t.Fatalf("analyze() error = %v", err)
+		}
+		hits, misses := repo.cache.Stats()
+		return result, hits, misses
+	}
+
+	// Three files are classified and package.json is parsed once, even
+	// though several rules look at its dependencies.
+	first, _, misses := analyze()
+	if misses != 4 {
+		t.Errorf("Expected the first run to compute 4 results, got %d", misses)
+	}
+
+	second, hits, misses := analyze()
+	if misses != 0 || hits == 0 {
+		t.Errorf("Expected the second run to reuse every result, got %d hits and %d misses", hits, misses)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected cached results to match\nfirst:  %+v\nsecond: %+v", first, second)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "worker.py"), []byte("import os\nimport sys\nprint(os, sys)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	third, _, misses := analyze()
+	if misses != 1 {
+		t.Errorf("Expected only the edited file to be re-processed, got %d misses", misses)
+	}
+
+	uncached, err := newRepository(root).analyze(context.Background(), &types.AnalysisOptions{Evidence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(third, uncached) {
+		t.Errorf("Expected the edit to be picked up\ncached:   %+v\nuncached: %+v", third.Components, uncached.Components)
+	}
+}
@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+type cargoManifest struct {
+	Package *struct {
+		RustVersion any    `toml:"rust-version"` // a string, or {workspace = true}
+		Workspace   string `toml:"workspace"`
+	} `toml:"package"`
+	Dependencies      map[string]any `toml:"dependencies"`
+	DevDependencies   map[string]any `toml:"dev-dependencies"`
+	BuildDependencies map[string]any `toml:"build-dependencies"`
+	Target            map[string]struct {
+		Dependencies      map[string]any `toml:"dependencies"`
+		DevDependencies   map[string]any `toml:"dev-dependencies"`
+		BuildDependencies map[string]any `toml:"build-dependencies"`
+	} `toml:"target"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+		Package struct {
+			RustVersion string `toml:"rust-version"`
+		} `toml:"package"`
+		Dependencies map[string]any `toml:"dependencies"`
+	} `toml:"workspace"`
+}
+
+type cargoDependency struct {
+	Name      string // the crate name, which differs from the key if renamed with package = "..."
+	Path      string
+	Workspace bool
+}
+
+func parseCargoManifest(content []byte) (*cargoManifest, error) {
+	var manifest cargoManifest
+	if _, err := toml.Decode(string(content), &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// dependencies returns the dependencies of all dependency tables, keyed by
+// the name they are declared under. [workspace.dependencies] only declares
+// versions for members to inherit and is not included.
+func (m *cargoManifest) dependencies() map[string]cargoDependency {
+	dependencies := make(map[string]cargoDependency)
+	add := func(table map[string]any) {
+		for key, value := range table {
+			dependencies[key] = parseCargoDependency(key, value)
+		}
+	}
+
+	add(m.Dependencies)
+	add(m.DevDependencies)
+	add(m.BuildDependencies)
+
+	targets := make([]string, 0, len(m.Target))
+	for target := range m.Target {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		add(m.Target[target].Dependencies)
+		add(m.Target[target].DevDependencies)
+		add(m.Target[target].BuildDependencies)
+	}
+
+	return dependencies
+}
+
+func parseCargoDependency(key string, value any) cargoDependency {
+	dependency := cargoDependency{Name: key}
+	if table, ok := value.(map[string]any); ok {
+		if name, ok := table["package"].(string); ok && name != "" {
+			dependency.Name = name
+		}
+		dependency.Path, _ = table["path"].(string)
+		dependency.Workspace, _ = table["workspace"].(bool)
+	}
+	return dependency
+}
+
+// cargoWorkspaceRoot returns the workspace the crate in dir belongs to: the
+// one package.workspace points to, or else the nearest parent with a
+// [workspace] table.
+func (r *repository) cargoWorkspaceRoot(dir string, manifest *cargoManifest) (string, *cargoManifest, []byte) {
+	var candidates []string
+	if manifest.Package != nil && manifest.Package.Workspace != "" {
+		candidates = append(candidates, path.Join(dir, manifest.Package.Workspace))
+	} else {
+		for parent := dir; parent != "."; {
+			parent = path.Dir(parent)
+			candidates = append(candidates, parent)
+		}
+	}
+
+	for _, candidate := range candidates {
+		cargoPath := path.Join(candidate, "Cargo.toml")
+		if !r.fileExists(cargoPath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, cargoPath)
+		if err != nil {
+			continue
+		}
+		root, err := parseCargoManifest(content)
+		if err == nil && root.Workspace != nil {
+			return candidate, root, content
+		}
+	}
+
+	return "", nil, nil
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestCargoManifestDependencies(t *testing.T) {
+	content := []byte(`[package]
+name = "api"
+# axum = "0.6" is not a dependency
+
+[dependencies]
+serde = { version = "1", features = ["derive"] }
+web = { package = "actix-web", version = "4" }
+shared = { workspace = true }
+
+[dev-dependencies.helpers]
+path = "../helpers"
+
+[build-dependencies]
+cc = "1"
+
+[target.'cfg(unix)'.dependencies]
+nix = "0.28"
+
+[workspace.dependencies]
+tokio = "1"
+`)
+
+	manifest, err := parseCargoManifest(content)
+	if err != nil {
+		t.Fatalf("parseCargoManifest() error = %v", err)
+	}
+
+	want := map[string]cargoDependency{
+		"serde":   {Name: "serde"},
+		"web":     {Name: "actix-web"},
+		"shared":  {Name: "shared", Workspace: true},
+		"helpers": {Name: "helpers", Path: "../helpers"},
+		"cc":      {Name: "cc"},
+		"nix":     {Name: "nix"},
+	}
+	if got := manifest.dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies() = %v, want %v", got, want)
+	}
+
+	declared := manifestDependencies("Cargo.toml", content)
+	if !declared["actix-web"] || declared["axum"] || declared["tokio"] {
+		t.Errorf("Unexpected declared dependencies %v", declared)
+	}
+}
+
+func TestCargoWorkspaceInheritance(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"Cargo.toml": `[workspace]
+members = ["crates/*"]
+
+[workspace.package]
+edition = "2021"
+rust-version = "1.75"
+
+[workspace.dependencies]
+utils = { path = "crates/utils" }
+serde = "1"
+`,
+		"crates/app/Cargo.toml": `[package]
+name = "app"
+rust-version.workspace = true
+
+[dependencies]
+utils.workspace = true
+serde = { workspace = true }
+`,
+		"crates/utils/Cargo.toml": "[package]\nname = \"utils\"\nrust-version = \"1.70\"\n",
+	})
+
+	tests := []struct {
+		dir  string
+		want map[string]string
+	}{
+		{".", map[string]string{"rust": "1.75"}},
+		{"crates/app", map[string]string{"rust": "1.75"}},
+		{"crates/utils", map[string]string{"rust": "1.70"}},
+	}
+	for _, tt := range tests {
+		got, err := ExtractVersionRequirements(fsys, tt.dir)
+		if err != nil {
+			t.Fatalf("ExtractVersionRequirements(%q) error = %v", tt.dir, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractVersionRequirements(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+
+	structure, err := DiscoverProjectStructure(fsys, "repo")
+	if err != nil {
+		t.Fatalf("DiscoverProjectStructure() error = %v", err)
+	}
+	deps, err := BuildDependencyGraph(fsys, structure.Components)
+	if err != nil {
+		t.Fatalf("BuildDependencyGraph() error = %v", err)
+	}
+
+	want := []types.ComponentDependency{
+		{From: "crates/app", To: "crates/utils", Kind: "cargo-path"},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("BuildDependencyGraph() = %v, want %v", deps, want)
+	}
+}
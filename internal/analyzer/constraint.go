@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isVersionRange reports whether a requirement such as ">=18", "^1.2" or
+// "3.*" is a range rather than a pinned version.
+func isVersionRange(value string) bool {
+	if strings.ContainsAny(value, "<>=!~^*|, ") {
+		return true
+	}
+	for _, part := range strings.Split(value, ".") {
+		if part == "x" || part == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// versionsConflict reports whether two values of a version requirement
+// disagree. Pins agree when one is a prefix of the other (3.11 and 3.11.8),
+// a range agrees with the pins it allows, and ranges never conflict.
+func versionsConflict(a, b string) bool {
+	aRange, bRange := isVersionRange(a), isVersionRange(b)
+	switch {
+	case aRange && bRange:
+		return false
+	case !aRange && !bRange:
+		aVersion, aOK := parseVersion(a)
+		bVersion, bOK := parseVersion(b)
+		if !aOK || !bOK {
+			return a != b
+		}
+		return !prefixEqual(aVersion, bVersion)
+	}
+
+	constraint, pin := a, b
+	if bRange {
+		constraint, pin = b, a
+	}
+	version, ok := parseVersion(pin)
+	if !ok {
+		return false
+	}
+	satisfied, ok := satisfiesConstraint(constraint, version)
+	return ok && !satisfied
+}
+
+// parseVersion returns the numeric components of a version such as
+// "v18.17.0" or "3.12.0rc1".
+func parseVersion(value string) ([]int, bool) {
+	value = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(value), "v"), "V")
+	var version []int
+	for _, part := range strings.Split(value, ".") {
+		end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if end == 0 {
+			break
+		}
+		if end > 0 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		version = append(version, n)
+		if end > 0 {
+			break
+		}
+	}
+	return version, len(version) > 0
+}
+
+func prefixEqual(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var constraintOperatorRegex = regexp.MustCompile(`([<>=!~^]+)\s+`)
+
+// satisfiesConstraint evaluates npm ranges and PEP 440 specifiers. ok is
+// false if the range cannot be evaluated.
+func satisfiesConstraint(constraint string, version []int) (satisfied, ok bool) {
+	constraint = constraintOperatorRegex.ReplaceAllString(constraint, "$1")
+	for _, alternative := range strings.Split(constraint, "||") {
+		all := true
+		for _, comparator := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+			satisfied, ok := satisfiesComparator(comparator, version)
+			if !ok {
+				return false, false
+			}
+			all = all && satisfied
+		}
+		if all {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func satisfiesComparator(comparator string, version []int) (satisfied, ok bool) {
+	end := strings.IndexFunc(comparator, func(r rune) bool { return !strings.ContainsRune("<>=!~^", r) })
+	if end < 0 {
+		return false, false
+	}
+	op, operand := comparator[:end], comparator[end:]
+	if operand == "*" || operand == "x" || operand == "X" {
+		return true, true
+	}
+	bound, ok := parseVersion(operand)
+	if !ok {
+		return false, false
+	}
+	wildcard := strings.HasSuffix(operand, "*") || strings.HasSuffix(operand, "x") || strings.HasSuffix(operand, "X")
+
+	switch op {
+	case "", "=":
+		return prefixEqual(version, bound), true
+	case "==", "===":
+		if wildcard {
+			return prefixEqual(version, bound), true
+		}
+		return compareVersions(version, bound) == 0 || spans(version, bound), true
+	case "!=":
+		if wildcard {
+			return !prefixEqual(version, bound) || len(version) < len(bound), true
+		}
+		return compareVersions(version, bound) != 0 || spans(version, bound), true
+	case ">=":
+		return compareVersions(version, bound) >= 0 || spans(version, bound), true
+	case ">":
+		return compareVersions(version, bound) > 0 || spans(version, bound), true
+	case "<=":
+		return compareVersions(version, bound) <= 0, true
+	case "<":
+		return compareVersions(version, bound) < 0 || spans(version, bound), true
+	case "^":
+		// ^ allows updates that keep the first non-zero component.
+		upper := make([]int, len(bound))
+		i := 0
+		for i < len(bound)-1 && bound[i] == 0 {
+			i++
+		}
+		copy(upper, bound[:i])
+		upper[i] = bound[i] + 1
+		return (compareVersions(version, bound) >= 0 || spans(version, bound)) && compareVersions(version, upper[:i+1]) < 0, true
+	case "~", "~=":
+		// ~1.2.3 allows patch updates, ~1 minor ones; ~=3.10 allows
+		// updates of all but the last component.
+		keep := 1
+		switch {
+		case op == "~" && len(bound) > 1:
+			keep = 2
+		case op == "~=" && len(bound) < 2:
+			return false, false
+		case op == "~=":
+			keep = len(bound) - 1
+		}
+		upper := append([]int(nil), bound[:keep]...)
+		upper[keep-1]++
+		return (compareVersions(version, bound) >= 0 || spans(version, bound)) && compareVersions(version, upper) < 0, true
+	}
+	return false, false
+}
+
+// compareVersions compares two versions, padding the shorter one with zeros.
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// spans reports whether the partial version covers bound, as 18 covers
+// 18.17.0, so that some version it stands for lies on either side of it.
+func spans(version, bound []int) bool {
+	return len(version) < len(bound) && prefixEqual(version, bound)
+}
@@ -0,0 +1,45 @@
+package analyzer
+
+import "testing"
+
+func TestVersionsConflict(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"18.17.0", "20.11.0", true},
+		{"18", "18.17.0", false},
+		{"3.11", "3.11.8", false},
+		{"v18.17.0", "18.17.0", false},
+		{"lts/iron", "20", true},
+		{">=18", "18.17.0", false},
+		{">=18", "16.20.0", true},
+		{"18", ">=18.17", false},
+		{"<19", "22", true},
+		{"^18.2.0", "18.19.1", false},
+		{"^18.2.0", "20.11.0", true},
+		{"^0.2.3", "0.3.0", true},
+		{"~1.2.3", "1.2.9", false},
+		{"~1.2.3", "1.3.0", true},
+		{"18.x", "18.19.1", false},
+		{"16 || 18", "18.19.1", false},
+		{"16 || 18", "20", true},
+		{">=3.10,<3.13", "3.12", false},
+		{">= 3.10, < 3.13", "3.13.1", true},
+		{"~=3.10", "3.12", false},
+		{"~=3.10", "4.0", true},
+		{"==3.11.*", "3.11.8", false},
+		{"!=3.11.*", "3.11.8", true},
+		{">=18", "<22", false},
+		{"lts/*", "18", false},
+	}
+
+	for _, tt := range tests {
+		if got := versionsConflict(tt.a, tt.b); got != tt.want {
+			t.Errorf("versionsConflict(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := versionsConflict(tt.b, tt.a); got != tt.want {
+			t.Errorf("versionsConflict(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
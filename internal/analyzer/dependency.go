@@ -1,38 +1,45 @@
 package analyzer
 
 import (
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/replyzer/analyze-repo/internal/types"
 	"gopkg.in/yaml.v3"
 )
 
-func DetectExternalDependencies(componentPath string) (*types.ExternalDependencies, error) {
-	deps := &types.ExternalDependencies{
-		Databases: make([]string, 0),
-		Services:  make([]string, 0),
+func DetectExternalDependencies(fsys fs.FS, dir string) (*types.ExternalDependencies, error) {
+	return newRepositoryFS("", fsys).externalDependencies(dir)
+}
+
+func (r *repository) externalDependencies(dir string) (*types.ExternalDependencies, error) {
+	component := &types.Component{
+		ExternalDependencies: types.ExternalDependencies{
+			Databases: make([]string, 0),
+			Services:  make([]string, 0),
+		},
 	}
 
-	if err := analyzeDockerCompose(componentPath, deps); err != nil {
-		return deps, err
+	if err := r.analyzeDockerCompose(dir, component); err != nil {
+		return &component.ExternalDependencies, err
 	}
 
-	if err := analyzeEnvironmentFiles(componentPath, deps); err != nil {
-		return deps, err
+	if err := r.analyzeEnvironmentFiles(dir, component); err != nil {
+		return &component.ExternalDependencies, err
 	}
 
-	return deps, nil
+	return &component.ExternalDependencies, nil
 }
 
-func analyzeDockerCompose(componentPath string, deps *types.ExternalDependencies) error {
+func (r *repository) analyzeDockerCompose(dir string, component *types.Component) error {
 	composeFiles := []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
 
 	for _, filename := range composeFiles {
-		composePath := filepath.Join(componentPath, filename)
-		if _, err := os.Stat(composePath); err == nil {
-			content, err := os.ReadFile(composePath)
+		composePath := path.Join(dir, filename)
+		if r.fileExists(composePath) {
+			content, err := fs.ReadFile(r.fsys, composePath)
 			if err != nil {
 				continue
 			}
@@ -50,9 +57,20 @@ func analyzeDockerCompose(componentPath string, deps *types.ExternalDependencies
 				continue
 			}
 
-			for serviceName, service := range compose.Services {
+			names := make([]string, 0, len(compose.Services))
+			for name := range compose.Services {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				service := compose.Services[name]
 				if service.Image != "" {
-					categorizeService(serviceName, service.Image, deps)
+					r.categorizeImage(service.Image, component, types.Evidence{
+						File:  composePath,
+						Line:  lineOf(content, service.Image),
+						Match: service.Image,
+					})
 				}
 			}
 		}
@@ -61,31 +79,28 @@ func analyzeDockerCompose(componentPath string, deps *types.ExternalDependencies
 	return nil
 }
 
-func analyzeEnvironmentFiles(componentPath string, deps *types.ExternalDependencies) error {
+func (r *repository) analyzeEnvironmentFiles(dir string, component *types.Component) error {
 	envFiles := []string{".env", ".env.local", ".env.development", ".env.production"}
 
 	for _, filename := range envFiles {
-		envPath := filepath.Join(componentPath, filename)
-		if _, err := os.Stat(envPath); err == nil {
-			content, err := os.ReadFile(envPath)
+		envPath := path.Join(dir, filename)
+		if r.fileExists(envPath) {
+			content, err := fs.ReadFile(r.fsys, envPath)
 			if err != nil {
 				continue
 			}
 
 			lines := strings.Split(string(content), "\n")
-			for _, line := range lines {
+			for i, line := range lines {
 				line = strings.TrimSpace(line)
 				if line == "" || strings.HasPrefix(line, "#") {
 					continue
 				}
 
-				if strings.Contains(line, "DATABASE_URL") || strings.Contains(line, "DB_") {
-					extractDatabaseFromEnv(line, deps)
-				}
-
-				if strings.Contains(line, "REDIS") || strings.Contains(line, "CACHE") {
-					extractServiceFromEnv(line, deps)
-				}
+				// Only the variable name is kept as evidence, values may
+				// hold credentials.
+				key, _, _ := strings.Cut(line, "=")
+				r.categorizeEnvLine(line, component, types.Evidence{File: envPath, Line: i + 1, Match: key})
 			}
 		}
 	}
@@ -93,110 +108,6 @@ func analyzeEnvironmentFiles(componentPath string, deps *types.ExternalDependenc
 	return nil
 }
 
-func categorizeService(serviceName, image string, deps *types.ExternalDependencies) {
-	imageLower := strings.ToLower(image)
-
-	databases := map[string]string{
-		"postgres":    "PostgreSQL",
-		"mysql":       "MySQL",
-		"mariadb":     "MariaDB", 
-		"mongodb":     "MongoDB",
-		"redis":       "Redis",
-		"elasticsearch": "Elasticsearch",
-		"cassandra":   "Cassandra",
-		"couchdb":     "CouchDB",
-		"neo4j":       "Neo4j",
-		"influxdb":    "InfluxDB",
-		"timescaledb": "TimescaleDB",
-	}
-
-	services := map[string]string{
-		"nginx":       "Nginx",
-		"apache":      "Apache",
-		"traefik":     "Traefik",
-		"rabbitmq":    "RabbitMQ",
-		"kafka":       "Apache Kafka",
-		"zookeeper":   "Apache Zookeeper",
-		"memcached":   "Memcached",
-		"consul":      "Consul",
-		"vault":       "HashiCorp Vault",
-		"prometheus":  "Prometheus",
-		"grafana":     "Grafana",
-		"jaeger":      "Jaeger",
-		"zipkin":      "Zipkin",
-		"minio":       "MinIO",
-	}
-
-	for key, dbName := range databases {
-		if strings.Contains(imageLower, key) {
-			if !contains(deps.Databases, dbName) {
-				deps.Databases = append(deps.Databases, dbName)
-			}
-			return
-		}
-	}
-
-	for key, serviceName := range services {
-		if strings.Contains(imageLower, key) {
-			if !contains(deps.Services, serviceName) {
-				deps.Services = append(deps.Services, serviceName)
-			}
-			return
-		}
-	}
-
-	if !strings.Contains(imageLower, "/") || strings.Contains(imageLower, "scratch") {
-		return
-	}
-
-	if !contains(deps.Services, image) {
-		deps.Services = append(deps.Services, image)
-	}
-}
-
-func extractDatabaseFromEnv(line string, deps *types.ExternalDependencies) {
-	lineLower := strings.ToLower(line)
-
-	databases := map[string]string{
-		"postgres":    "PostgreSQL",
-		"mysql":       "MySQL", 
-		"mariadb":     "MariaDB",
-		"mongodb":     "MongoDB",
-		"sqlite":      "SQLite",
-		"redis":       "Redis",
-		"elasticsearch": "Elasticsearch",
-	}
-
-	for key, dbName := range databases {
-		if strings.Contains(lineLower, key) {
-			if !contains(deps.Databases, dbName) {
-				deps.Databases = append(deps.Databases, dbName)
-			}
-			break
-		}
-	}
-}
-
-func extractServiceFromEnv(line string, deps *types.ExternalDependencies) {
-	lineLower := strings.ToLower(line)
-
-	services := map[string]string{
-		"redis":     "Redis",
-		"memcached": "Memcached",
-		"rabbitmq":  "RabbitMQ",
-		"kafka":     "Apache Kafka",
-	}
-
-	for key, serviceName := range services {
-		if strings.Contains(lineLower, key) {
-			if !contains(deps.Services, serviceName) {
-				deps.Services = append(deps.Services, serviceName)
-			}
-			break
-		}
-	}
-}
-
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
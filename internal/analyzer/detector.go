@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// Detector records its findings on a component. Detectors run after language
+// stats are set and before the component type is inferred, and must be safe
+// for concurrent use.
+type Detector interface {
+	Name() string
+	Detect(target *Target, component *types.Component) error
+}
+
+type Target struct {
+	Context context.Context
+	FS      fs.FS  // rooted at the repository root
+	Dir     string // component directory within FS, "." for the root
+	Info    types.ComponentInfo
+
+	repo *repository
+}
+
+func (t *Target) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(t.FS, path.Join(t.Dir, name))
+}
+
+func (t *Target) Exists(name string) bool {
+	_, err := fs.Stat(t.FS, path.Join(t.Dir, name))
+	return err == nil
+}
+
+var (
+	detectorsMu sync.RWMutex
+	detectors   []Detector
+)
+
+// RegisterDetector panics if a detector with the same name is already
+// registered.
+func RegisterDetector(detector Detector) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+
+	name := detector.Name()
+	if name == "" {
+		panic("analyzer: detector name must not be empty")
+	}
+	for _, existing := range detectors {
+		if existing.Name() == name {
+			panic(fmt.Sprintf("analyzer: detector %s registered twice", name))
+		}
+	}
+	detectors = append(detectors, detector)
+}
+
+func Detectors() []Detector {
+	detectorsMu.RLock()
+	defer detectorsMu.RUnlock()
+	return append([]Detector(nil), detectors...)
+}
+
+func DetectorNames() []string {
+	var names []string
+	for _, detector := range Detectors() {
+		names = append(names, detector.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Disabling an unknown detector is an error, so that typos in flags do not
+// go unnoticed.
+func enabledDetectors(disabled []string) ([]Detector, error) {
+	all := Detectors()
+
+	skip := make(map[string]bool)
+	for _, name := range disabled {
+		found := false
+		for _, detector := range all {
+			if detector.Name() == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown detector %q (available: %v)", name, DetectorNames())
+		}
+		skip[name] = true
+	}
+
+	enabled := make([]Detector, 0, len(all))
+	for _, detector := range all {
+		if !skip[detector.Name()] {
+			enabled = append(enabled, detector)
+		}
+	}
+	return enabled, nil
+}
+
+type builtinDetector struct {
+	name   string
+	detect func(r *repository, dir string, component *types.Component) error
+}
+
+func (d builtinDetector) Name() string { return d.name }
+
+func (d builtinDetector) Detect(target *Target, component *types.Component) error {
+	return d.detect(target.repo, target.Dir, component)
+}
+
+func init() {
+	for _, detector := range []Detector{
+		builtinDetector{"frameworks", func(r *repository, dir string, component *types.Component) error {
+			candidates, evidence, err := r.frameworkCandidates(dir, component.PrimaryLanguage)
+			if err != nil {
+				return err
+			}
+			if len(candidates) > 0 {
+				component.Framework = candidates[0].Name
+			}
+			component.FrameworkCandidates = candidates
+			addEvidence(component, evidence...)
+			return nil
+		}},
+		builtinDetector{"node-version", (*repository).extractNodeVersions},
+		builtinDetector{"python-version", (*repository).extractPythonVersions},
+		builtinDetector{"java-version", (*repository).extractJavaVersions},
+		builtinDetector{"go-version", (*repository).extractGoVersions},
+		builtinDetector{"rust-version", (*repository).extractRustVersions},
+		builtinDetector{"dotnet-version", (*repository).extractDotNetVersions},
+		builtinDetector{"version-managers", (*repository).extractVersionManagerVersions},
+		builtinDetector{"docker-compose", (*repository).analyzeDockerCompose},
+		builtinDetector{"env-files", (*repository).analyzeEnvironmentFiles},
+		builtinDetector{"dev-tools", (*repository).developmentTools},
+	} {
+		RegisterDetector(detector)
+	}
+}
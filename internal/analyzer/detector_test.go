@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+type rpcDetector struct{}
+
+func (rpcDetector) Name() string { return "test-rpc" }
+
+func (rpcDetector) Detect(target *Target, component *types.Component) error {
+	if !target.Exists("service.rpc.yaml") {
+		return nil
+	}
+	component.Framework = "InHouseRPC"
+
+	content, err := target.ReadFile("service.rpc.yaml")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), "config-service") {
+		component.ExternalDependencies.Services = append(component.ExternalDependencies.Services, "ConfigService")
+	}
+	return nil
+}
+
+var registerRPC sync.Once
+
+func TestDetectors(t *testing.T) {
+	registerRPC.Do(func() { RegisterDetector(rpcDetector{}) })
+
+	fsys := fstest.MapFS{
+		"billing/go.mod":           &fstest.MapFile{Data: []byte("module example.com/billing\n\ngo 1.22\n")},
+		"billing/main.go":          &fstest.MapFile{Data: []byte("package main\n\nfunc main() {\n\tprintln(\"billing\")\n}\n")},
+		"billing/service.rpc.yaml": &fstest.MapFile{Data: []byte("dependencies:\n  - config-service\n")},
+		"billing/.env":             &fstest.MapFile{Data: []byte("DATABASE_URL=postgres://localhost/billing\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "repo", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	comp := result.Components[0]
+	if comp.Framework != "InHouseRPC" || comp.Type != "api-service" {
+		t.Errorf("Expected the registered detector to set the framework, got %s (%s)", comp.Framework, comp.Type)
+	}
+	if len(comp.ExternalDependencies.Services) != 1 || comp.ExternalDependencies.Services[0] != "ConfigService" {
+		t.Errorf("Expected ConfigService, got %v", comp.ExternalDependencies.Services)
+	}
+	if len(comp.ExternalDependencies.Databases) != 1 {
+		t.Errorf("Expected PostgreSQL from .env, got %v", comp.ExternalDependencies.Databases)
+	}
+
+	result, err = AnalyzeFS(context.Background(), fsys, "repo", &types.AnalysisOptions{
+		DisabledDetectors: []string{"env-files", "go-version"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	comp = result.Components[0]
+	if len(comp.ExternalDependencies.Databases) != 0 {
+		t.Errorf("Expected env-files to be disabled, got %v", comp.ExternalDependencies.Databases)
+	}
+	if _, exists := comp.VersionRequirements["go"]; exists {
+		t.Errorf("Expected go-version to be disabled, got %v", comp.VersionRequirements)
+	}
+
+	_, err = AnalyzeFS(context.Background(), fsys, "repo", &types.AnalysisOptions{DisabledDetectors: []string{"env-file"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown detector "env-file"`) {
+		t.Errorf("Expected an unknown detector error, got %v", err)
+	}
+}
+
+func TestRegisterDetectorRejectsDuplicates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a duplicate detector to panic")
+		}
+	}()
+	RegisterDetector(builtinDetector{name: "env-files"})
+}
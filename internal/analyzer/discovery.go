@@ -2,8 +2,10 @@ package analyzer
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/replyzer/analyze-repo/internal/types"
@@ -13,48 +15,46 @@ var configFiles = []string{
 	"package.json",
 	"requirements.txt",
 	"pyproject.toml",
+	"Pipfile",
+	"setup.py",
+	"environment.yml",
+	"environment.yaml",
 	"pom.xml",
 	"build.gradle",
+	"build.gradle.kts",
+	"settings.gradle",
+	"settings.gradle.kts",
 	"Cargo.toml",
 	"go.mod",
+	"go.work",
 	"*.csproj",
 	"docker-compose.yml",
 	"docker-compose.yaml",
 }
 
-func DiscoverProjectStructure(repoPath string) (*types.ProjectStructure, error) {
+// Component paths are reported relative to repoPath.
+func DiscoverProjectStructure(fsys fs.FS, repoPath string) (*types.ProjectStructure, error) {
+	return newRepositoryFS(repoPath, fsys).discover()
+}
+
+func (r *repository) discover() (*types.ProjectStructure, error) {
 	components := make(map[string]*types.ComponentInfo)
 	
-	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		if info.IsDir() {
-			if shouldSkipDir(info.Name()) {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		fileName := info.Name()
+	index, err := r.buildIndex(func(relPath string, d fs.DirEntry) {
+		fileName := d.Name()
 		if isConfigFile(fileName) {
-			dir := filepath.Dir(path)
-			relDir, err := filepath.Rel(repoPath, dir)
-			if err != nil {
-				return err
-			}
-
+			relDir := filepath.FromSlash(path.Dir(relPath))
 			if relDir == "." {
 				relDir = ""
 			}
+			dir := filepath.Join(r.root, relDir)
 
 			componentName := getComponentName(dir, relDir)
 			
-			if comp, exists := components[componentName]; exists {
+			if comp, exists := components[relDir]; exists {
 				comp.ConfigFiles = append(comp.ConfigFiles, fileName)
 			} else {
-				components[componentName] = &types.ComponentInfo{
+				components[relDir] = &types.ComponentInfo{
 					Name:         componentName,
 					Path:         dir,
 					RelativePath: relDir,
@@ -62,27 +62,41 @@ func DiscoverProjectStructure(repoPath string) (*types.ProjectStructure, error)
 				}
 			}
 		}
-
-		return nil
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
+	componentDirs := make([]string, 0, len(components))
+	for _, comp := range components {
+		componentDirs = append(componentDirs, componentDir(*comp))
+	}
+	index.assignOwners(componentDirs)
+	r.index = index
+
+	workspaces, err := r.resolveWorkspaces(components)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspaces: %w", err)
+	}
+
 	componentList := make([]types.ComponentInfo, 0, len(components))
 	for _, comp := range components {
 		componentList = append(componentList, *comp)
 	}
+	sort.Slice(componentList, func(i, j int) bool {
+		return filepath.ToSlash(componentList[i].RelativePath) < filepath.ToSlash(componentList[j].RelativePath)
+	})
 
 	repoType := "single"
-	if len(componentList) > 1 {
+	if len(componentList) > 1 || len(workspaces) > 0 {
 		repoType = "monorepo"
 	}
 
 	return &types.ProjectStructure{
 		Type:       repoType,
 		Components: componentList,
+		Workspaces: workspaces,
 	}, nil
 }
 
@@ -101,34 +115,6 @@ func isConfigFile(fileName string) bool {
 	return false
 }
 
-func shouldSkipDir(dirName string) bool {
-	skipDirs := []string{
-		".git",
-		"node_modules",
-		".venv",
-		"venv",
-		"__pycache__",
-		".pytest_cache",
-		"target",
-		"build",
-		"dist",
-		".next",
-		".nuxt",
-		"coverage",
-		".coverage",
-		"bin",
-		"obj",
-	}
-
-	for _, skip := range skipDirs {
-		if dirName == skip {
-			return true
-		}
-	}
-
-	return strings.HasPrefix(dirName, ".")
-}
-
 func getComponentName(fullPath, relativePath string) string {
 	if relativePath == "" {
 		return filepath.Base(fullPath)
@@ -0,0 +1,258 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func findComponent(structure *types.ProjectStructure, relativePath string) *types.ComponentInfo {
+	for i := range structure.Components {
+		if filepath.ToSlash(structure.Components[i].RelativePath) == relativePath {
+			return &structure.Components[i]
+		}
+	}
+	return nil
+}
+
+func TestDiscoverJSWorkspaces(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expectedTool    string
+		expectedMembers []string
+	}{
+		{
+			name: "npm workspaces",
+			files: map[string]string{
+				"package.json":              `{"name": "root", "workspaces": ["packages/*"]}`,
+				"packages/ui/package.json":  `{"name": "ui"}`,
+				"packages/api/package.json": `{"name": "api"}`,
+				"tools/package.json":        `{"name": "tools"}`,
+			},
+			expectedTool:    "npm",
+			expectedMembers: []string{"packages/api", "packages/ui"},
+		},
+		{
+			name: "yarn classic workspaces object",
+			files: map[string]string{
+				"package.json":                 `{"workspaces": {"packages": ["apps/**"], "nohoist": ["**/react-native"]}}`,
+				"yarn.lock":                    "",
+				"apps/web/package.json":        `{"name": "web"}`,
+				"apps/mobile/ios/package.json": `{"name": "ios"}`,
+			},
+			expectedTool:    "yarn",
+			expectedMembers: []string{"apps/mobile/ios", "apps/web"},
+		},
+		{
+			name: "pnpm workspace with exclusion",
+			files: map[string]string{
+				"package.json":                 `{"name": "root"}`,
+				"pnpm-workspace.yaml":          "packages:\n  - 'packages/*'\n  - '!packages/legacy'\n",
+				"packages/core/package.json":   `{"name": "core"}`,
+				"packages/legacy/package.json": `{"name": "legacy"}`,
+			},
+			expectedTool:    "pnpm",
+			expectedMembers: []string{"packages/core"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structure, err := DiscoverProjectStructure(mapFS(tt.files), "repo")
+			if err != nil {
+				t.Fatalf("DiscoverProjectStructure() error = %v", err)
+			}
+
+			if structure.Type != "monorepo" {
+				t.Errorf("Expected monorepo, got %s", structure.Type)
+			}
+
+			if len(structure.Workspaces) != 1 {
+				t.Fatalf("Expected 1 workspace, got %d", len(structure.Workspaces))
+			}
+
+			workspace := structure.Workspaces[0]
+			if workspace.Tool != tt.expectedTool {
+				t.Errorf("Expected tool %s, got %s", tt.expectedTool, workspace.Tool)
+			}
+			if workspace.Path != "." {
+				t.Errorf("Expected workspace path '.', got %s", workspace.Path)
+			}
+			if !reflect.DeepEqual(workspace.Members, tt.expectedMembers) {
+				t.Errorf("Expected members %v, got %v", tt.expectedMembers, workspace.Members)
+			}
+
+			rootComp := findComponent(structure, "")
+			if rootComp == nil || !rootComp.WorkspaceRoot {
+				t.Errorf("Expected root component to be marked as workspace root")
+			}
+
+			for _, member := range tt.expectedMembers {
+				comp := findComponent(structure, member)
+				if comp == nil {
+					t.Fatalf("Member %s was not discovered", member)
+				}
+				if comp.Workspace != "." {
+					t.Errorf("Expected %s to belong to workspace '.', got %q", member, comp.Workspace)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverMultiModuleWorkspaces(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expectedTool    string
+		expectedMembers []string
+	}{
+		{
+			name: "cargo virtual manifest",
+			files: map[string]string{
+				"Cargo.toml":                     "[workspace]\nmembers = [\n  \"crates/*\", # all crates\n  \"tools/cli\",\n]\nexclude = [\"crates/experimental\"]\n",
+				"crates/core/Cargo.toml":         "[package]\nname = \"core\"\n",
+				"crates/experimental/Cargo.toml": "[package]\nname = \"experimental\"\n",
+				"tools/cli/Cargo.toml":           "[package]\nname = \"cli\"\n",
+			},
+			expectedTool:    "cargo",
+			expectedMembers: []string{"crates/core", "tools/cli"},
+		},
+		{
+			name: "go.work",
+			files: map[string]string{
+				"go.work":          "go 1.22\n\nuse (\n\t./api\n\t./shared // common code\n)\nuse ./tools\n",
+				"api/go.mod":       "module example.com/api\n",
+				"shared/go.mod":    "module example.com/shared\n",
+				"tools/go.mod":     "module example.com/tools\n",
+				"unrelated/go.mod": "module example.com/unrelated\n",
+			},
+			expectedTool:    "go",
+			expectedMembers: []string{"api", "shared", "tools"},
+		},
+		{
+			name: "maven parent pom",
+			files: map[string]string{
+				"pom.xml":      "<project><packaging>pom</packaging><modules><module>core</module><module>web</module></modules></project>",
+				"core/pom.xml": "<project><artifactId>core</artifactId></project>",
+				"web/pom.xml":  "<project><artifactId>web</artifactId></project>",
+			},
+			expectedTool:    "maven",
+			expectedMembers: []string{"core", "web"},
+		},
+		{
+			name: "gradle settings",
+			files: map[string]string{
+				"settings.gradle.kts":    "rootProject.name = \"demo\"\ninclude(\":app\", \":libs:util\")\n",
+				"build.gradle.kts":       "",
+				"app/build.gradle.kts":   "",
+				"libs/util/build.gradle": "",
+			},
+			expectedTool:    "gradle",
+			expectedMembers: []string{"app", "libs/util"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structure, err := DiscoverProjectStructure(mapFS(tt.files), "repo")
+			if err != nil {
+				t.Fatalf("DiscoverProjectStructure() error = %v", err)
+			}
+
+			if len(structure.Workspaces) != 1 {
+				t.Fatalf("Expected 1 workspace, got %v", structure.Workspaces)
+			}
+
+			workspace := structure.Workspaces[0]
+			if workspace.Tool != tt.expectedTool {
+				t.Errorf("Expected tool %s, got %s", tt.expectedTool, workspace.Tool)
+			}
+			if !reflect.DeepEqual(workspace.Members, tt.expectedMembers) {
+				t.Errorf("Expected members %v, got %v", tt.expectedMembers, workspace.Members)
+			}
+
+			rootComp := findComponent(structure, "")
+			if rootComp == nil || !rootComp.WorkspaceRoot {
+				t.Errorf("Expected root component to be marked as workspace root")
+			}
+		})
+	}
+}
+
+func TestGradleIncludeStatements(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"settings.gradle":               "include 'api',\n        ':services:billing'\ninclude \"web\"\n",
+		"api/build.gradle":              "",
+		"services/billing/build.gradle": "",
+		"web/build.gradle":              "",
+	})
+
+	spec, err := newRepositoryFS("repo", fsys).detectGradleWorkspace(".")
+	if err != nil {
+		t.Fatalf("detectGradleWorkspace() error = %v", err)
+	}
+
+	expected := []string{"api", "services/billing", "web"}
+	if spec == nil || !reflect.DeepEqual(spec.patterns, expected) {
+		t.Errorf("Expected projects %v, got %+v", expected, spec)
+	}
+}
+
+func TestDiscoverWithoutWorkspaces(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"frontend/package.json": `{"name": "frontend"}`,
+		"backend/go.mod":        "module example.com/backend\n",
+	})
+
+	structure, err := DiscoverProjectStructure(fsys, "repo")
+	if err != nil {
+		t.Fatalf("DiscoverProjectStructure() error = %v", err)
+	}
+
+	if len(structure.Workspaces) != 0 {
+		t.Errorf("Expected no workspaces, got %v", structure.Workspaces)
+	}
+
+	for _, comp := range structure.Components {
+		if comp.Workspace != "" || comp.WorkspaceRoot {
+			t.Errorf("Component %s should not be linked to a workspace", comp.Name)
+		}
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"packages/*", "packages/ui", true},
+		{"packages/*", "packages/ui/nested", false},
+		{"./packages/*/", "packages/ui", true},
+		{"apps/**", "apps/web/client", true},
+		{"**/client", "apps/web/client", true},
+		{"libs/core", "libs/core", true},
+		{"libs/core", "libs/core-extra", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if result := matchPathGlob(tt.pattern, tt.name); result != tt.expected {
+				t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/rules"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+const (
+	evidenceFramework = "framework"
+	evidenceVersion   = "version"
+	evidenceTool      = "tool"
+)
+
+// lineOf returns the 1-based line of the first needle found, or 0.
+func lineOf(content []byte, needles ...string) int {
+	for _, needle := range needles {
+		if i := bytes.Index(content, []byte(needle)); i >= 0 {
+			return bytes.Count(content[:i], []byte("\n")) + 1
+		}
+	}
+	return 0
+}
+
+// tomlKeyLine returns the line of a dotted key such as
+// "project.requires-python", written under its table header or dotted, or 0.
+func tomlKeyLine(content []byte, key string) int {
+	table := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(strings.TrimSpace(strings.SplitN(line, "#", 2)[0]), "[]")
+			continue
+		}
+
+		name, _, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		name = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(name), `"`, ""), " ", "")
+		if table != "" {
+			name = table + "." + name
+		}
+		if name == key || strings.HasPrefix(name, key+".") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// setVersion records a version requirement. Later sources replace earlier
+// ones, and a conflict is recorded if they disagree.
+func setVersion(component *types.Component, key, value string, evidence types.Evidence) {
+	if previous, exists := component.VersionRequirements[key]; exists && previous != value {
+		winner := types.VersionSource{File: evidence.File, Value: value}
+		if versionsConflict(previous, value) {
+			addVersionConflict(component, key, previous, winner)
+		} else {
+			setConflictWinner(component, key, winner)
+		}
+	}
+	component.VersionRequirements[key] = value
+
+	evidence.Kind, evidence.Name = evidenceVersion, key
+	for i, existing := range component.Evidence {
+		if existing.Kind == evidenceVersion && existing.Name == key {
+			component.Evidence[i] = evidence
+			return
+		}
+	}
+	component.Evidence = append(component.Evidence, evidence)
+}
+
+func addVersionConflict(component *types.Component, key, previous string, winner types.VersionSource) {
+	overridden := types.VersionSource{Value: previous}
+	for _, existing := range component.Evidence {
+		if existing.Kind == evidenceVersion && existing.Name == key {
+			overridden.File = existing.File
+			break
+		}
+	}
+
+	for i := range component.VersionConflicts {
+		if conflict := &component.VersionConflicts[i]; conflict.Key == key {
+			conflict.Value, conflict.File = winner.Value, winner.File
+			conflict.Overridden = append(conflict.Overridden, overridden)
+			return
+		}
+	}
+	component.VersionConflicts = append(component.VersionConflicts, types.VersionConflict{
+		Key:        key,
+		Value:      winner.Value,
+		File:       winner.File,
+		Overridden: []types.VersionSource{overridden},
+	})
+}
+
+// setConflictWinner updates an existing conflict of key when a later source
+// replaces its winner with a value it agrees with.
+func setConflictWinner(component *types.Component, key string, winner types.VersionSource) {
+	for i := range component.VersionConflicts {
+		if conflict := &component.VersionConflicts[i]; conflict.Key == key {
+			conflict.Value, conflict.File = winner.Value, winner.File
+		}
+	}
+}
+
+func addService(component *types.Component, rule rules.Rule, evidence types.Evidence) {
+	list := &component.ExternalDependencies.Services
+	if rule.Kind == rules.KindDatabase {
+		list = &component.ExternalDependencies.Databases
+	}
+	if !contains(*list, rule.Name) {
+		*list = append(*list, rule.Name)
+	}
+
+	evidence.Kind, evidence.Name = rule.Kind, rule.Name
+	addEvidence(component, evidence)
+}
+
+func addEvidence(component *types.Component, evidence ...types.Evidence) {
+	for _, e := range evidence {
+		duplicate := false
+		for _, existing := range component.Evidence {
+			if existing == e {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			component.Evidence = append(component.Evidence, e)
+		}
+	}
+}
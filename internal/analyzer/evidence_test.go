@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestEvidence(t *testing.T) {
+	fsys := fstest.MapFS{
+		"package.json": &fstest.MapFile{Data: []byte(`{
+  "name": "web",
+  "engines": {
+    "node": ">=18"
+  },
+  "dependencies": {
+    "express": "^4.19.0"
+  },
+  "devDependencies": {
+    "jest": "^29.0.0"
+  }
+}
+`)},
+		".nvmrc":          &fstest.MapFile{Data: []byte("20.11.0\n")},
+		"server.js":       &fstest.MapFile{Data: []byte(strings.Repeat("const app = require('express')();\n", 20))},
+		"compose.yaml":    &fstest.MapFile{Data: []byte("services:\n  db:\n    image: postgres:16\n")},
+		".env.production": &fstest.MapFile{Data: []byte("# production\nDATABASE_URL=postgres://app:s3cret@db/app\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if result.Components[0].Evidence != nil {
+		t.Errorf("Expected no evidence unless requested, got %v", result.Components[0].Evidence)
+	}
+
+	result, err = AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{Evidence: true})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	want := []types.Evidence{
+		{Kind: "framework", Name: "Express", File: "package.json", Line: 7, Match: "express"},
+		{Kind: "framework", Name: "Express", File: "server.js", Line: 1, Match: "express"},
+		{Kind: "version", Name: "node", File: ".nvmrc", Line: 1, Match: "20.11.0"},
+		{Kind: "database", Name: "PostgreSQL", File: "compose.yaml", Line: 3, Match: "postgres:16"},
+		{Kind: "database", Name: "PostgreSQL", File: ".env.production", Line: 2, Match: "DATABASE_URL"},
+		{Kind: "tool", Name: "Jest", File: "package.json", Line: 10, Match: "jest"},
+	}
+	if got := result.Components[0].Evidence; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected evidence:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLineOf(t *testing.T) {
+	content := []byte("a\nb = 1\nc\n")
+	if line := lineOf(content, "missing", "b ="); line != 2 {
+		t.Errorf("lineOf() = %d, want 2", line)
+	}
+	if line := lineOf(content, "missing"); line != 0 {
+		t.Errorf("lineOf() = %d, want 0", line)
+	}
+}
+
+func TestTomlKeyLine(t *testing.T) {
+	content := []byte("[package]\nname = \"app\"\nrust-version.workspace = true\n\n[workspace.package] # shared\nrust-version = \"1.75\"\n")
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"package.name", 2},
+		{"package.rust-version", 3},
+		{"workspace.package.rust-version", 6},
+		{"package.edition", 0},
+	}
+	for _, tt := range tests {
+		if line := tomlKeyLine(content, tt.key); line != tt.want {
+			t.Errorf("tomlKeyLine(%q) = %d, want %d", tt.key, line, tt.want)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+type graphBuilder struct {
+	repo   *repository
+	byPath map[string]*types.ComponentInfo
+	edges  map[types.ComponentDependency]bool
+}
+
+func BuildDependencyGraph(fsys fs.FS, components []types.ComponentInfo) ([]types.ComponentDependency, error) {
+	return newRepositoryFS("", fsys).dependencyGraph(components)
+}
+
+func (r *repository) dependencyGraph(components []types.ComponentInfo) ([]types.ComponentDependency, error) {
+	builder := &graphBuilder{
+		repo:   r,
+		byPath: make(map[string]*types.ComponentInfo),
+		edges:  make(map[types.ComponentDependency]bool),
+	}
+	for i := range components {
+		builder.byPath[workspacePath(components[i].RelativePath)] = &components[i]
+	}
+
+	if err := builder.addNodeDependencies(components); err != nil {
+		return nil, err
+	}
+
+	for i := range components {
+		comp := &components[i]
+		if err := builder.addGoReplaces(comp); err != nil {
+			return nil, err
+		}
+		if err := builder.addCargoPaths(comp); err != nil {
+			return nil, err
+		}
+		if err := builder.addComposeDependencies(comp); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := builder.addMavenDependencies(components); err != nil {
+		return nil, err
+	}
+
+	deps := make([]types.ComponentDependency, 0, len(builder.edges))
+	for edge := range builder.edges {
+		deps = append(deps, edge)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].From != deps[j].From {
+			return deps[i].From < deps[j].From
+		}
+		if deps[i].To != deps[j].To {
+			return deps[i].To < deps[j].To
+		}
+		return deps[i].Kind < deps[j].Kind
+	})
+
+	return deps, nil
+}
+
+// BuildOrder returns components after their dependencies, and the
+// components of dependency cycles separately.
+func BuildOrder(nodes []string, deps []types.ComponentDependency) ([]string, []string) {
+	inDegree := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, node := range nodes {
+		inDegree[node] = 0
+	}
+	for _, dep := range deps {
+		if _, ok := inDegree[dep.From]; !ok {
+			continue
+		}
+		if _, ok := inDegree[dep.To]; !ok {
+			continue
+		}
+		inDegree[dep.From]++
+		dependents[dep.To] = append(dependents[dep.To], dep.From)
+	}
+
+	var ready []string
+	for node, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, node)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]string, 0, len(inDegree))
+	for len(ready) > 0 {
+		node := ready[0]
+		ready = ready[1:]
+		order = append(order, node)
+
+		var unlocked []string
+		for _, dependent := range dependents[node] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				unlocked = append(unlocked, dependent)
+			}
+		}
+		ready = append(ready, unlocked...)
+		sort.Strings(ready)
+	}
+
+	var cyclic []string
+	for node, degree := range inDegree {
+		if degree > 0 {
+			cyclic = append(cyclic, node)
+		}
+	}
+	sort.Strings(cyclic)
+
+	return order, cyclic
+}
+
+func (b *graphBuilder) addEdge(from *types.ComponentInfo, toPath, kind string) {
+	fromPath := workspacePath(from.RelativePath)
+	if _, exists := b.byPath[toPath]; !exists || toPath == fromPath {
+		return
+	}
+	b.edges[types.ComponentDependency{From: fromPath, To: toPath, Kind: kind}] = true
+}
+
+func (b *graphBuilder) readFile(comp *types.ComponentInfo, name string) ([]byte, error) {
+	return fs.ReadFile(b.repo.fsys, path.Join(componentDir(*comp), name))
+}
+
+func resolveLocalPath(comp *types.ComponentInfo, target string) string {
+	return workspacePath(path.Join(filepath.ToSlash(comp.RelativePath), filepath.ToSlash(target)))
+}
+
+func (b *graphBuilder) addNodeDependencies(components []types.ComponentInfo) error {
+	type packageJson struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+
+	packages := make(map[string]packageJson)
+	byName := make(map[string]string)
+	for i := range components {
+		comp := &components[i]
+		if !hasConfigFile(comp.ConfigFiles, "package.json") {
+			continue
+		}
+
+		data, err := b.readFile(comp, "package.json")
+		if err != nil {
+			return err
+		}
+
+		var pkg packageJson
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			continue
+		}
+
+		compPath := workspacePath(comp.RelativePath)
+		packages[compPath] = pkg
+		if pkg.Name != "" {
+			byName[pkg.Name] = compPath
+		}
+	}
+
+	for i := range components {
+		comp := &components[i]
+		pkg, exists := packages[workspacePath(comp.RelativePath)]
+		if !exists {
+			continue
+		}
+
+		for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+			for name, version := range deps {
+				switch {
+				case strings.HasPrefix(version, "file:"), strings.HasPrefix(version, "link:"), strings.HasPrefix(version, "portal:"):
+					target := version[strings.Index(version, ":")+1:]
+					b.addEdge(comp, resolveLocalPath(comp, target), "file")
+				case strings.HasPrefix(version, "workspace:"):
+					if target, ok := byName[name]; ok {
+						b.addEdge(comp, target, "workspace")
+					}
+				default:
+					// npm and Yarn classic link sibling workspace packages by name
+					// even when they are referenced with a plain version range.
+					target, ok := byName[name]
+					if ok && comp.Workspace != "" && b.byPath[target].Workspace == comp.Workspace {
+						b.addEdge(comp, target, "workspace")
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+var goReplaceRegex = regexp.MustCompile(`=>\s*(\S+)\s*$`)
+
+func (b *graphBuilder) addGoReplaces(comp *types.ComponentInfo) error {
+	if !hasConfigFile(comp.ConfigFiles, "go.mod") {
+		return nil
+	}
+
+	content, err := b.readFile(comp, "go.mod")
+	if err != nil {
+		return err
+	}
+
+	inBlock := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "replace (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case !inBlock && !strings.HasPrefix(line, "replace "):
+			continue
+		}
+
+		matches := goReplaceRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		target := matches[1]
+		if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+			b.addEdge(comp, resolveLocalPath(comp, target), "go-replace")
+		}
+	}
+
+	return nil
+}
+
+func (b *graphBuilder) addCargoPaths(comp *types.ComponentInfo) error {
+	if !hasConfigFile(comp.ConfigFiles, "Cargo.toml") {
+		return nil
+	}
+
+	content, err := b.readFile(comp, "Cargo.toml")
+	if err != nil {
+		return err
+	}
+
+	manifest, err := parseCargoManifest(content)
+	if err != nil {
+		return nil
+	}
+
+	var rootDir string
+	var root *cargoManifest
+	rootLoaded := false
+	for key, dependency := range manifest.dependencies() {
+		if dependency.Path != "" {
+			b.addEdge(comp, resolveLocalPath(comp, dependency.Path), "cargo-path")
+			continue
+		}
+		if !dependency.Workspace {
+			continue
+		}
+
+		// Inherited dependencies take their path from the workspace root.
+		if !rootLoaded {
+			rootDir, root, _ = b.repo.cargoWorkspaceRoot(componentDir(*comp), manifest)
+			rootLoaded = true
+		}
+		if root == nil {
+			continue
+		}
+		if inherited := parseCargoDependency(key, root.Workspace.Dependencies[key]); inherited.Path != "" {
+			b.addEdge(comp, workspacePath(path.Join(rootDir, inherited.Path)), "cargo-path")
+		}
+	}
+
+	return nil
+}
+
+type mavenCoordinates struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+}
+
+func (b *graphBuilder) addMavenDependencies(components []types.ComponentInfo) error {
+	type pomFile struct {
+		mavenCoordinates
+		Parent       mavenCoordinates   `xml:"parent"`
+		Dependencies []mavenCoordinates `xml:"dependencies>dependency"`
+	}
+
+	poms := make(map[string]pomFile)
+	modules := make(map[mavenCoordinates]string)
+	for i := range components {
+		comp := &components[i]
+		if !hasConfigFile(comp.ConfigFiles, "pom.xml") {
+			continue
+		}
+
+		content, err := b.readFile(comp, "pom.xml")
+		if err != nil {
+			return err
+		}
+
+		var pom pomFile
+		if err := xml.Unmarshal(content, &pom); err != nil {
+			continue
+		}
+		if pom.GroupID == "" {
+			pom.GroupID = pom.Parent.GroupID
+		}
+
+		compPath := workspacePath(comp.RelativePath)
+		poms[compPath] = pom
+		modules[pom.mavenCoordinates] = compPath
+	}
+
+	for i := range components {
+		comp := &components[i]
+		pom, exists := poms[workspacePath(comp.RelativePath)]
+		if !exists {
+			continue
+		}
+
+		for _, dep := range pom.Dependencies {
+			if dep.GroupID == "${project.groupId}" || dep.GroupID == "${project.parent.groupId}" {
+				dep.GroupID = pom.GroupID
+			}
+			if target, ok := modules[dep]; ok {
+				b.addEdge(comp, target, "maven-module")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *graphBuilder) addComposeDependencies(comp *types.ComponentInfo) error {
+	for _, filename := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+		content, err := b.readFile(comp, filename)
+		if err != nil {
+			continue
+		}
+
+		var compose struct {
+			Services map[string]struct {
+				Build     yaml.Node `yaml:"build"`
+				DependsOn yaml.Node `yaml:"depends_on"`
+			} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(content, &compose); err != nil {
+			continue
+		}
+
+		serviceComponents := make(map[string]string)
+		for name, service := range compose.Services {
+			if buildContext := composeBuildContext(&service.Build); buildContext != "" {
+				serviceComponents[name] = resolveLocalPath(comp, buildContext)
+			}
+		}
+
+		for name, service := range compose.Services {
+			fromPath, ok := serviceComponents[name]
+			if !ok {
+				continue
+			}
+			from, ok := b.byPath[fromPath]
+			if !ok {
+				continue
+			}
+			for _, dependency := range composeDependsOn(&service.DependsOn) {
+				if toPath, ok := serviceComponents[dependency]; ok {
+					b.addEdge(from, toPath, "compose")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// The build key is either a context path or a mapping with a context entry.
+func composeBuildContext(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.MappingNode:
+		var build struct {
+			Context string `yaml:"context"`
+		}
+		if node.Decode(&build) == nil && build.Context != "" {
+			return build.Context
+		}
+		return "."
+	}
+	return ""
+}
+
+// depends_on is either a list of service names or a mapping keyed by them.
+func composeDependsOn(node *yaml.Node) []string {
+	var services []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		_ = node.Decode(&services)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			services = append(services, node.Content[i].Value)
+		}
+	}
+	return services
+}
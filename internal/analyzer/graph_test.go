@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestBuildDependencyGraph(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"package.json":                 `{"name": "root", "private": true, "workspaces": ["packages/*", "apps/*"]}`,
+		"packages/ui/package.json":     `{"name": "@acme/ui", "dependencies": {"@acme/tokens": "*"}}`,
+		"packages/tokens/package.json": `{"name": "@acme/tokens"}`,
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"@acme/ui": "workspace:*", "react": "^18.0.0"}, "devDependencies": {"config": "file:../../config"}}`,
+		"config/package.json":          `{"name": "config"}`,
+		"services/api/go.mod":          "module example.com/api\n\nrequire example.com/shared v0.0.0\n\nreplace example.com/shared => ../shared\n",
+		"services/shared/go.mod":       "module example.com/shared\n",
+		"crates/app/Cargo.toml":        "[package]\nname = \"app\"\n\n[dependencies]\nserde = \"1\"\nutils = { path = \"../utils\" }\n\n[dev-dependencies.helpers]\npath = \"../helpers\"\n",
+		"crates/utils/Cargo.toml":      "[package]\nname = \"utils\"\n",
+		"crates/helpers/Cargo.toml":    "[package]\nname = \"helpers\"\n",
+		"java/pom.xml":                 "<project><groupId>com.acme</groupId><artifactId>parent</artifactId><modules><module>core</module><module>web</module></modules></project>",
+		"java/core/pom.xml":            "<project><parent><groupId>com.acme</groupId></parent><artifactId>core</artifactId></project>",
+		"java/web/pom.xml":             "<project><parent><groupId>com.acme</groupId></parent><artifactId>web</artifactId><dependencies><dependency><groupId>${project.groupId}</groupId><artifactId>core</artifactId></dependency><dependency><groupId>org.slf4j</groupId><artifactId>slf4j-api</artifactId></dependency></dependencies></project>",
+		"deploy/docker-compose.yml":    "services:\n  api:\n    build: ../services/api\n    depends_on:\n      - shared\n      - db\n  shared:\n    build:\n      context: ../services/shared\n  db:\n    image: postgres:16\n",
+	})
+
+	structure, err := DiscoverProjectStructure(fsys, "repo")
+	if err != nil {
+		t.Fatalf("DiscoverProjectStructure() error = %v", err)
+	}
+
+	deps, err := BuildDependencyGraph(fsys, structure.Components)
+	if err != nil {
+		t.Fatalf("BuildDependencyGraph() error = %v", err)
+	}
+
+	expected := []types.ComponentDependency{
+		{From: "apps/web", To: "config", Kind: "file"},
+		{From: "apps/web", To: "packages/ui", Kind: "workspace"},
+		{From: "crates/app", To: "crates/helpers", Kind: "cargo-path"},
+		{From: "crates/app", To: "crates/utils", Kind: "cargo-path"},
+		{From: "java/web", To: "java/core", Kind: "maven-module"},
+		{From: "packages/ui", To: "packages/tokens", Kind: "workspace"},
+		{From: "services/api", To: "services/shared", Kind: "compose"},
+		{From: "services/api", To: "services/shared", Kind: "go-replace"},
+	}
+
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("BuildDependencyGraph() =\n%v\nwant\n%v", deps, expected)
+	}
+}
+
+func TestBuildOrder(t *testing.T) {
+	deps := []types.ComponentDependency{
+		{From: "apps/web", To: "packages/ui", Kind: "workspace"},
+		{From: "packages/ui", To: "packages/tokens", Kind: "workspace"},
+		{From: "apps/docs", To: "packages/ui", Kind: "workspace"},
+	}
+	nodes := []string{"apps/web", "apps/docs", "packages/ui", "packages/tokens", "tools"}
+
+	order, cyclic := BuildOrder(nodes, deps)
+
+	expected := []string{"packages/tokens", "packages/ui", "apps/docs", "apps/web", "tools"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("BuildOrder() = %v, want %v", order, expected)
+	}
+	if len(cyclic) != 0 {
+		t.Errorf("Expected no cycles, got %v", cyclic)
+	}
+}
+
+func TestBuildOrderWithCycle(t *testing.T) {
+	deps := []types.ComponentDependency{
+		{From: "a", To: "b", Kind: "file"},
+		{From: "b", To: "a", Kind: "file"},
+		{From: "c", To: "a", Kind: "file"},
+	}
+
+	order, cyclic := BuildOrder([]string{"a", "b", "c", "d"}, deps)
+
+	if !reflect.DeepEqual(order, []string{"d"}) {
+		t.Errorf("Expected only d to be ordered, got %v", order)
+	}
+	if !reflect.DeepEqual(cyclic, []string{"a", "b", "c"}) {
+		t.Errorf("Expected a, b and c to be unresolved, got %v", cyclic)
+	}
+}
@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Imports are at the top of a file in every supported language.
+const maxImportScanBytes = 16 * 1024
+
+type sourceImport struct {
+	module string
+	file   string
+	line   int
+}
+
+var (
+	jsImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:import|export)\s[^'"]*?\bfrom\s*['"]([^'"]+)['"]`),
+		regexp.MustCompile(`^\s*import\s*['"]([^'"]+)['"]`),
+		regexp.MustCompile(`\b(?:require|import)\(\s*['"]([^'"]+)['"]\s*\)`),
+	}
+	pythonImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+([\w.]+)`),
+		regexp.MustCompile(`^\s*from\s+([\w.]+)\s+import\b`),
+	}
+	goImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`),
+	}
+	goImportBlockPattern = regexp.MustCompile(`^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+	jvmImportPatterns    = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+)`),
+	}
+	rustImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:pub\s+)?use\s+([\w:]+)`),
+		regexp.MustCompile(`^\s*extern\s+crate\s+(\w+)`),
+	}
+	csharpImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*using\s+(?:static\s+)?([\w.]+)\s*;`),
+	}
+	phpImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*use\s+\\?([\w\\]+)`),
+	}
+	rubyImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*require\s*\(?\s*['"]([^'"]+)['"]`),
+	}
+)
+
+var importPatterns = map[string][]*regexp.Regexp{
+	".js": jsImportPatterns, ".jsx": jsImportPatterns, ".mjs": jsImportPatterns, ".cjs": jsImportPatterns,
+	".ts": jsImportPatterns, ".tsx": jsImportPatterns, ".mts": jsImportPatterns, ".cts": jsImportPatterns,
+	".vue": jsImportPatterns, ".svelte": jsImportPatterns,
+	".py":   pythonImportPatterns,
+	".go":   goImportPatterns,
+	".java": jvmImportPatterns, ".kt": jvmImportPatterns, ".scala": jvmImportPatterns,
+	".rs":  rustImportPatterns,
+	".cs":  csharpImportPatterns,
+	".php": phpImportPatterns,
+	".rb":  rubyImportPatterns,
+}
+
+func (r *repository) sourceImports(dir string) ([]sourceImport, error) {
+	var imports []sourceImport
+
+	err := r.eachFile(dir, func(filePath string) {
+		patterns, exists := importPatterns[path.Ext(filePath)]
+		if !exists {
+			return
+		}
+
+		file, err := r.fsys.Open(filePath)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+
+		imports = append(imports, scanImports(file, filePath, patterns)...)
+	})
+
+	return imports, err
+}
+
+func scanImports(reader io.Reader, filePath string, patterns []*regexp.Regexp) []sourceImport {
+	var imports []sourceImport
+
+	inGoBlock := false
+	scanner := bufio.NewScanner(io.LimitReader(reader, maxImportScanBytes))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if path.Ext(filePath) == ".go" {
+			trimmed := strings.TrimSpace(text)
+			switch {
+			case strings.HasPrefix(trimmed, "import ("):
+				inGoBlock = true
+				continue
+			case inGoBlock && trimmed == ")":
+				inGoBlock = false
+				continue
+			case inGoBlock:
+				if m := goImportBlockPattern.FindStringSubmatch(text); m != nil {
+					imports = append(imports, sourceImport{m[1], filePath, line})
+				}
+				continue
+			}
+		}
+
+		for _, pattern := range patterns {
+			for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+				imports = append(imports, sourceImport{m[1], filePath, line})
+			}
+		}
+	}
+
+	return imports
+}
+
+// importsModule reports whether module is prefix or a submodule of it.
+func importsModule(module, prefix string) bool {
+	if module == prefix {
+		return true
+	}
+	if !strings.HasPrefix(module, prefix) {
+		return false
+	}
+
+	switch rest := module[len(prefix):]; {
+	case strings.HasPrefix(rest, "/"), strings.HasPrefix(rest, "."),
+		strings.HasPrefix(rest, "::"), strings.HasPrefix(rest, `\`):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSourceImports(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/page.tsx": &fstest.MapFile{Data: []byte(`import Link from "next/link";
+import { useState } from 'react'
+import './globals.css'
+const lazy = () => import("lodash")
+`)},
+		"app/main.py":   &fstest.MapFile{Data: []byte("import os.path\nfrom fastapi import FastAPI\n")},
+		"app/main.go":   &fstest.MapFile{Data: []byte("package main\n\nimport (\n\t\"fmt\"\n\tgin \"github.com/gin-gonic/gin\"\n)\n")},
+		"app/App.java":  &fstest.MapFile{Data: []byte("import static org.junit.Assert.assertTrue;\nimport org.springframework.boot.SpringApplication;\n")},
+		"app/main.rs":   &fstest.MapFile{Data: []byte("use axum::Router;\nextern crate serde;\n")},
+		"app/Api.cs":    &fstest.MapFile{Data: []byte("using Microsoft.AspNetCore.Mvc;\n")},
+		"app/Home.php":  &fstest.MapFile{Data: []byte("<?php\nuse Illuminate\\Http\\Request;\n")},
+		"app/app.rb":    &fstest.MapFile{Data: []byte("require 'rails/all'\n")},
+		"app/README.md": &fstest.MapFile{Data: []byte("import React from 'react'\n")},
+	}
+
+	imports, err := newRepositoryFS("", fsys).sourceImports("app")
+	if err != nil {
+		t.Fatalf("sourceImports() error = %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, imported := range imports {
+		got[imported.file] = append(got[imported.file], imported.module)
+		if imported.module == "react" && imported.line != 2 {
+			t.Errorf("Expected react to be imported on line 2, got %d", imported.line)
+		}
+	}
+	want := map[string][]string{
+		"app/page.tsx": {"next/link", "react", "./globals.css", "lodash"},
+		"app/main.py":  {"os.path", "fastapi"},
+		"app/main.go":  {"fmt", "github.com/gin-gonic/gin"},
+		"app/App.java": {"org.junit.Assert.assertTrue", "org.springframework.boot.SpringApplication"},
+		"app/main.rs":  {"axum::Router", "serde"},
+		"app/Api.cs":   {"Microsoft.AspNetCore.Mvc"},
+		"app/Home.php": {"Illuminate\\Http\\Request"},
+		"app/app.rb":   {"rails/all"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sourceImports() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestImportsModule(t *testing.T) {
+	tests := []struct {
+		module, prefix string
+		want           bool
+	}{
+		{"next", "next", true},
+		{"next/link", "next", true},
+		{"nextra", "next", false},
+		{"react-dom", "react", false},
+		{"org.springframework.boot.SpringApplication", "org.springframework", true},
+		{"axum::Router", "axum", true},
+		{"Illuminate\\Http\\Request", "Illuminate", true},
+	}
+
+	for _, tt := range tests {
+		if got := importsModule(tt.module, tt.prefix); got != tt.want {
+			t.Errorf("importsModule(%q, %q) = %v, want %v", tt.module, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// fileIndex is the file tree built by the walk during discovery, so that
+// per-component analysis does not walk again.
+type fileIndex struct {
+	// Ignored entries are kept so that lookups of files such as a gitignored
+	// .env behave as on the file system.
+	entries    map[string]bool // path -> ignored
+	walked     map[string]bool // directories whose entries are complete
+	children   map[string][]string
+	files      []string            // non-ignored files
+	owned      map[string][]string // files of each component, without nested components
+	components map[string]bool
+}
+
+// buildIndex calls visit for each file that is not ignored.
+func (r *repository) buildIndex(visit func(relPath string, d fs.DirEntry)) (*fileIndex, error) {
+	index := &fileIndex{
+		entries:  make(map[string]bool),
+		walked:   make(map[string]bool),
+		children: make(map[string][]string),
+	}
+
+	err := fs.WalkDir(r.fsys, ".", func(relPath string, d fs.DirEntry, err error) error {
+		if ctxErr := r.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+
+		if relPath != "." {
+			ignored := r.ignore.Ignored(relPath, d.IsDir())
+			index.entries[relPath] = ignored
+			parent := path.Dir(relPath)
+			index.children[parent] = append(index.children[parent], relPath)
+			if ignored {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if d.IsDir() {
+			index.walked[relPath] = true
+			return nil
+		}
+
+		index.files = append(index.files, relPath)
+		visit(relPath, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// assignOwners attributes every file to the nearest component containing it.
+func (index *fileIndex) assignOwners(componentDirs []string) {
+	index.components = make(map[string]bool, len(componentDirs))
+	for _, dir := range componentDirs {
+		index.components[dir] = true
+	}
+
+	index.owned = make(map[string][]string, len(componentDirs))
+	for _, file := range index.files {
+		for dir := path.Dir(file); ; dir = path.Dir(dir) {
+			if index.components[dir] {
+				index.owned[dir] = append(index.owned[dir], file)
+				break
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+}
+
+func (index *fileIndex) ownedFiles(dir string) ([]string, bool) {
+	if index == nil || !index.components[dir] {
+		return nil, false
+	}
+	return index.owned[dir], true
+}
+
+// exists also reports whether the index can answer, which requires the
+// parent directory to have been walked.
+func (index *fileIndex) exists(name string) (bool, bool) {
+	if index == nil {
+		return false, false
+	}
+	if name == "." {
+		return true, true
+	}
+	if !index.walked[path.Dir(name)] {
+		return false, false
+	}
+	_, exists := index.entries[name]
+	return exists, true
+}
+
+// glob also reports whether the index can answer, which requires a pattern
+// without meta characters in its directory.
+func (index *fileIndex) glob(pattern string) ([]string, bool) {
+	if index == nil {
+		return nil, false
+	}
+	dir, base := path.Split(pattern)
+	dir = path.Clean(dir)
+	if strings.ContainsAny(dir, `*?[\`) || !index.walked[dir] {
+		return nil, false
+	}
+
+	var matches []string
+	for _, child := range index.children[dir] {
+		if matched, _ := path.Match(base, path.Base(child)); matched {
+			matches = append(matches, child)
+		}
+	}
+	return matches, true
+}
+
+// eachFile calls fn for the files the component in dir owns, or for every
+// file below dir if the index does not cover it.
+func (r *repository) eachFile(dir string, fn func(filePath string)) error {
+	if files, indexed := r.index.ownedFiles(dir); indexed {
+		for _, file := range files {
+			if err := r.ctx.Err(); err != nil {
+				return err
+			}
+			fn(file)
+		}
+		return nil
+	}
+
+	return r.walk(dir, func(relPath string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			fn(relPath)
+		}
+		return nil
+	})
+}
+
+func (r *repository) fileExists(name string) bool {
+	if exists, indexed := r.index.exists(name); indexed {
+		return exists
+	}
+	_, err := fs.Stat(r.fsys, name)
+	return err == nil
+}
+
+func (r *repository) glob(pattern string) ([]string, error) {
+	if matches, indexed := r.index.glob(pattern); indexed {
+		return matches, nil
+	}
+	return fs.Glob(r.fsys, pattern)
+}
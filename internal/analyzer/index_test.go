@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// countingFS records how often each directory is read.
+type countingFS struct {
+	fstest.MapFS
+
+	mu       sync.Mutex
+	readDirs map[string]int
+}
+
+func (c *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.mu.Lock()
+	c.readDirs[name]++
+	c.mu.Unlock()
+	return c.MapFS.ReadDir(name)
+}
+
+func TestAnalyzeWalksOnce(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: mapFS(map[string]string{
+			".gitignore":                ".env\n*.gen.go\n",
+			".env":                      "DATABASE_URL=postgres://localhost/app\n",
+			"package.json":              `{"name": "root", "workspaces": ["services/*"], "dependencies": {"express": "4.0.0"}}`,
+			"server.js":                 strings.Repeat("const app = require('express')();\n", 10),
+			"services/api/go.mod":       "module example.com/api\n\ngo 1.22\n",
+			"services/api/main.go":      strings.Repeat("package main\n", 40),
+			"services/api/api.gen.go":   strings.Repeat("package main\n", 400),
+			"services/api/cmd/tool.go":  strings.Repeat("package main\n", 10),
+			"services/web/package.json": `{"name": "web"}`,
+			"services/web/index.ts":     strings.Repeat("export const web = 1;\n", 10),
+		}),
+		readDirs: make(map[string]int),
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "repo", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	for dir, count := range fsys.readDirs {
+		if count > 1 {
+			t.Errorf("Directory %s was read %d times", dir, count)
+		}
+	}
+
+	stats := make(map[string][]string)
+	for _, comp := range result.Components {
+		for language := range comp.LanguageStats {
+			stats[comp.Path] = append(stats[comp.Path], language)
+		}
+	}
+
+	for path, want := range map[string][]string{
+		"":             {"JSON", "JavaScript"},
+		"services/api": {"Go", "Go Module"},
+		"services/web": {"JSON", "TypeScript"},
+	} {
+		got := stats[path]
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %q to count only its own files as %v, got %v", path, want, got)
+		}
+	}
+
+	root := result.Components[0]
+	if root.Framework != "Express" || !reflect.DeepEqual(root.ExternalDependencies.Databases, []string{"PostgreSQL"}) {
+		t.Errorf("Expected ignored files to remain visible to lookups, got %s %+v", root.Framework, root.ExternalDependencies)
+	}
+}
+
+func TestFileIndex(t *testing.T) {
+	r := newRepositoryFS("", mapFS(map[string]string{
+		".gitignore":          "secret.txt\n",
+		"a/go.mod":            "module a\n",
+		"a/secret.txt":        "x",
+		"a/b/c.csproj":        "<Project/>",
+		"a/b/d.csproj":        "<Project/>",
+		"node_modules/x/x.js": "x",
+	}))
+
+	index, err := r.buildIndex(func(string, fs.DirEntry) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.assignOwners([]string{"a", "a/b"})
+
+	if files, _ := index.ownedFiles("a"); !reflect.DeepEqual(files, []string{"a/go.mod"}) {
+		t.Errorf("Unexpected files owned by a: %v", files)
+	}
+	if _, indexed := index.ownedFiles("node_modules"); indexed {
+		t.Error("Expected non-component directories not to be answered by the index")
+	}
+
+	tests := []struct {
+		name            string
+		exists, indexed bool
+	}{
+		{"a/secret.txt", true, true},
+		{"a/missing", false, true},
+		{"a/b", true, true},
+		{"node_modules/x/x.js", false, false},
+	}
+	for _, tt := range tests {
+		exists, indexed := index.exists(tt.name)
+		if exists != tt.exists || indexed != tt.indexed {
+			t.Errorf("exists(%q) = %v, %v, want %v, %v", tt.name, exists, indexed, tt.exists, tt.indexed)
+		}
+	}
+
+	if matches, indexed := index.glob("a/b/*.csproj"); !indexed || !reflect.DeepEqual(matches, []string{"a/b/c.csproj", "a/b/d.csproj"}) {
+		t.Errorf("glob() = %v, %v", matches, indexed)
+	}
+	if _, indexed := index.glob("*/b/*.csproj"); indexed {
+		t.Error("Expected patterns with meta characters in the directory to fall back to the file system")
+	}
+}
@@ -1,459 +1,357 @@
 package analyzer
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/go-enry/go-enry/v2"
+	"github.com/replyzer/analyze-repo/internal/attributes"
+	"github.com/replyzer/analyze-repo/internal/rules"
+	"github.com/replyzer/analyze-repo/internal/types"
 )
 
-var frameworkPatterns = map[string][]string{
-	"React":      {"react", "@types/react"},
-	"Vue":        {"vue", "@vue/cli", "vue-cli"},
-	"Angular":    {"@angular/core", "@angular/cli"},
-	"Next.js":    {"next"},
-	"Nuxt":       {"nuxt"},
-	"Express":    {"express"},
-	"Fastify":    {"fastify"},
-	"Nest":       {"@nestjs/core"},
-	"Django":     {"django", "Django"},
-	"FastAPI":    {"fastapi"},
-	"Flask":      {"flask", "Flask"},
-	"Spring":     {"org.springframework"},
-	"SpringBoot": {"org.springframework.boot"},
-	"Laravel":    {"laravel/framework"},
-	"Symfony":    {"symfony/framework-bundle"},
-	"Rails":      {"rails"},
-	"Gin":        {"github.com/gin-gonic/gin"},
-	"Echo":       {"github.com/labstack/echo"},
-	"Fiber":      {"github.com/gofiber/fiber"},
-	"Axum":       {"axum"},
-	"Actix":      {"actix-web"},
-	"Rocket":     {"rocket"},
-	"ASP.NET":    {"Microsoft.AspNetCore"},
+// Reasons for leaving a file out of the language stats.
+const (
+	skipTooLarge      = "too large"
+	skipBinary        = "binary"
+	skipVendored      = "vendored"
+	skipGenerated     = "generated"
+	skipDocumentation = "documentation"
+)
+
+const binarySniffLen = 8000
+
+// Metrics language stats can be based on.
+const (
+	StatsModeBytes = "bytes"
+	StatsModeLines = "lines"
+	StatsModeFiles = "files"
+)
+
+func GetLanguageStats(fsys fs.FS, dir string) (map[string]float64, error) {
+	return newRepositoryFS("", fsys).languageStats(dir)
 }
 
-func GetLanguageStats(path string) (map[string]float64, error) {
-	var languages map[string]int
-	var err error
-	
-	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
-		return make(map[string]float64), nil
+func (r *repository) languageStats(dir string) (map[string]float64, error) {
+	counts, err := r.languageCounts(dir)
+	if err != nil {
+		return nil, err
 	}
-	
-	err = filepath.Walk(path, func(filePath string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return nil
-		}
-		
-		if info.IsDir() {
-			return nil
-		}
-		
-		if shouldSkipFile(info.Name(), filePath) {
-			return nil
+	return languageShares(counts, StatsModeBytes), nil
+}
+
+// languageCounts returns the files, bytes and lines of each language in the
+// component in dir. After discovery only the files the component owns are
+// counted, so nested components are not counted twice.
+func (r *repository) languageCounts(dir string) (map[string]types.LanguageCount, error) {
+	counts := make(map[string]types.LanguageCount)
+
+	err := r.eachFile(dir, func(filePath string) {
+		if shouldSkipFile(path.Base(filePath)) {
+			return
 		}
 		
-		content, readErr := os.ReadFile(filePath)
+		classified, readErr := r.classifyFile(filePath)
 		if readErr != nil {
-			return nil
+			return
 		}
-		
-		language := enry.GetLanguage(filePath, content)
-		if language == "" {
-			return nil
+		if classified.Skipped != "" {
+			r.skipped.add(classified.Skipped)
+			return
 		}
-		
-		if languages == nil {
-			languages = make(map[string]int)
+		if classified.Language == "" {
+			return
 		}
 		
-		languages[language] += len(content)
-		return nil
+		count := counts[classified.Language]
+		count.Files++
+		count.Bytes += classified.Bytes
+		count.Lines += classified.Lines.Lines
+		count.Code += classified.Lines.Code
+		count.Comments += classified.Lines.Comments
+		count.Blank += classified.Lines.Blank
+		counts[classified.Language] = count
 	})
 	
 	if err != nil {
 		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
-	if len(languages) == 0 {
-		return make(map[string]float64), nil
+	return counts, nil
+}
+
+func languageShares(counts map[string]types.LanguageCount, mode string) map[string]float64 {
+	metric := func(count types.LanguageCount) int {
+		switch mode {
+		case StatsModeLines:
+			return count.Code
+		case StatsModeFiles:
+			return count.Files
+		}
+		return count.Bytes
 	}
 
 	total := 0
-	for _, bytes := range languages {
-		total += bytes
+	for _, count := range counts {
+		total += metric(count)
 	}
 
 	stats := make(map[string]float64)
-	for language, bytes := range languages {
-		if total > 0 {
-			percentage := (float64(bytes) / float64(total)) * 100
-			stats[language] = percentage
-		}
+	if total == 0 {
+		return stats
 	}
-
-	return stats, nil
-}
-
-func GetPrimaryLanguage(stats map[string]float64) string {
-	if len(stats) == 0 {
-		return ""
+	for language, count := range counts {
+		stats[language] = (float64(metric(count)) / float64(total)) * 100
 	}
 
-	var maxLang string
-	var maxPercentage float64
-
-	for lang, percentage := range stats {
-		if percentage > maxPercentage {
-			maxPercentage = percentage
-			maxLang = lang
-		}
-	}
-
-	return maxLang
+	return stats
 }
 
-func DetectFrameworks(componentPath string, primaryLang string) (string, error) {
-	switch strings.ToLower(primaryLang) {
-	case "javascript", "typescript":
-		return detectJSFramework(componentPath)
-	case "python":
-		return detectPythonFramework(componentPath)
-	case "java":
-		return detectJavaFramework(componentPath)
-	case "go":
-		return detectGoFramework(componentPath)
-	case "rust":
-		return detectRustFramework(componentPath)
-	case "c#":
-		return detectDotNetFramework(componentPath)
-	case "php":
-		return detectPHPFramework(componentPath)
-	case "ruby":
-		return detectRubyFramework(componentPath)
-	}
-
-	return "", nil
+type fileLanguage struct {
+	Language string
+	Bytes    int
+	Lines    lineCounts
+	Skipped  string // reason the file is left out of the stats
 }
 
-func detectJSFramework(componentPath string) (string, error) {
-	packageJsonPath := filepath.Join(componentPath, "package.json")
-	if _, err := os.Stat(packageJsonPath); os.IsNotExist(err) {
-		return "", nil
+// linguist attributes in .gitattributes take precedence over the heuristics.
+func (r *repository) classifyFile(filePath string) (fileLanguage, error) {
+	attrs := r.attributes.Linguist(filePath)
+	switch {
+	case orDefault(attrs.Vendored, enry.IsVendor, filePath):
+		return fileLanguage{Skipped: skipVendored}, nil
+	case orDefault(attrs.Documentation, enry.IsDocumentation, filePath):
+		return fileLanguage{Skipped: skipDocumentation}, nil
 	}
 
-	data, err := os.ReadFile(packageJsonPath)
+	info, err := fs.Stat(r.fsys, filePath)
 	if err != nil {
-		return "", err
+		return fileLanguage{}, err
 	}
-
-	var packageJson struct {
-		Dependencies    map[string]string `json:"dependencies"`
-		DevDependencies map[string]string `json:"devDependencies"`
+	if r.maxFileSize > 0 && info.Size() > r.maxFileSize {
+		return fileLanguage{Skipped: skipTooLarge}, nil
 	}
 
-	if err := json.Unmarshal(data, &packageJson); err != nil {
-		return "", err
-	}
+	var content fileContent
+	if !r.cache.Get(filePath, info, "", cacheLanguage, &content) {
+		// Look at the start of the file first, so that binaries are
+		// recognized without reading them completely.
+		binary, err := r.isBinary(filePath)
+		if err != nil {
+			return fileLanguage{}, err
+		}
+		if binary {
+			return fileLanguage{Skipped: skipBinary}, nil
+		}
 
-	allDeps := make(map[string]string)
-	for pkg, version := range packageJson.Dependencies {
-		allDeps[pkg] = version
-	}
-	for pkg, version := range packageJson.DevDependencies {
-		allDeps[pkg] = version
+		err = r.readCached(filePath, cacheLanguage, &content, func(data []byte) {
+			detected := enry.GetLanguage(filePath, data)
+			language := linguistLanguage(attrs, detected)
+			content = fileContent{
+				Language:  detected,
+				Bytes:     len(data),
+				Generated: enry.IsGenerated(filePath, data),
+				Lines:     countLines(data, language),
+				CountedAs: language,
+			}
+		})
+		if err != nil {
+			return fileLanguage{}, err
+		}
 	}
 
-	return detectFrameworkFromDependencies(allDeps), nil
-}
-
-func shouldSkipFile(fileName, filePath string) bool {
-	if strings.HasPrefix(fileName, ".") && fileName != ".env" && !strings.HasPrefix(fileName, ".env.") {
-		return true
-	}
-	
-	skipExtensions := []string{
-		".exe", ".dll", ".so", ".dylib", ".a", ".lib",
-		".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".ico",
-		".mp4", ".mp3", ".wav", ".avi", ".mov",
-		".zip", ".tar", ".gz", ".7z", ".rar",
-		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
-	}
-	
-	ext := strings.ToLower(filepath.Ext(fileName))
-	for _, skipExt := range skipExtensions {
-		if ext == skipExt {
-			return true
-		}
+	if attrs.Generated != nil {
+		content.Generated = *attrs.Generated
 	}
-	
-	if strings.Contains(filePath, "node_modules") ||
-		strings.Contains(filePath, ".git") ||
-		strings.Contains(filePath, "__pycache__") ||
-		strings.Contains(filePath, ".venv") ||
-		strings.Contains(filePath, "venv") ||
-		strings.Contains(filePath, "target") ||
-		strings.Contains(filePath, "build") ||
-		strings.Contains(filePath, "dist") {
-		return true
+	if content.Generated {
+		return fileLanguage{Skipped: skipGenerated}, nil
 	}
-	
-	return false
-}
 
-func detectPythonFramework(componentPath string) (string, error) {
-	requirementsPath := filepath.Join(componentPath, "requirements.txt")
-	if _, err := os.Stat(requirementsPath); err == nil {
-		content, err := os.ReadFile(requirementsPath)
+	language := linguistLanguage(attrs, content.Language)
+	if content.CountedAs != language {
+		// The linguist-language attribute changed since the file was cached.
+		data, err := fs.ReadFile(r.fsys, filePath)
 		if err != nil {
-			return "", err
+			return fileLanguage{}, err
 		}
-
-		deps := make(map[string]string)
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			parts := strings.Split(line, "==")
-			if len(parts) > 0 {
-				deps[strings.TrimSpace(parts[0])] = ""
-			}
-		}
-
-		return detectFrameworkFromDependencies(deps), nil
+		content.Lines = countLines(data, language)
 	}
+	return fileLanguage{Language: language, Bytes: content.Bytes, Lines: content.Lines}, nil
+}
 
-	pyprojectPath := filepath.Join(componentPath, "pyproject.toml")
-	if _, err := os.Stat(pyprojectPath); err == nil {
-		return "", nil
+func linguistLanguage(attrs attributes.Linguist, detected string) string {
+	if attrs.Language == "" {
+		return detected
+	}
+	if canonical, ok := enry.GetLanguageByAlias(attrs.Language); ok {
+		return canonical
 	}
+	return attrs.Language
+}
 
-	return "", nil
+func orDefault(attr *bool, heuristic func(string) bool, filePath string) bool {
+	if attr != nil {
+		return *attr
+	}
+	return heuristic(filePath)
 }
 
-func detectJavaFramework(componentPath string) (string, error) {
-	pomPath := filepath.Join(componentPath, "pom.xml")
-	if _, err := os.Stat(pomPath); err == nil {
-		content, err := os.ReadFile(pomPath)
-		if err != nil {
-			return "", err
-		}
+// Like git, files with a NUL byte near the start are binary.
+func (r *repository) isBinary(filePath string) (bool, error) {
+	file, err := r.fsys.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
 
-		contentStr := string(content)
-		for framework, patterns := range frameworkPatterns {
-			for _, pattern := range patterns {
-				if strings.Contains(contentStr, pattern) {
-					return framework, nil
-				}
-			}
-		}
+	head := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
 	}
+	return enry.IsBinary(head[:n]), nil
+}
 
-	return "", nil
+type skippedFiles struct {
+	mu     sync.Mutex
+	counts map[string]int
 }
 
-func detectGoFramework(componentPath string) (string, error) {
-	goModPath := filepath.Join(componentPath, "go.mod")
-	if _, err := os.Stat(goModPath); err == nil {
-		content, err := os.ReadFile(goModPath)
-		if err != nil {
-			return "", err
-		}
+func (s *skippedFiles) add(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		contentStr := string(content)
-		for framework, patterns := range frameworkPatterns {
-			for _, pattern := range patterns {
-				if strings.Contains(contentStr, pattern) {
-					return framework, nil
-				}
-			}
-		}
+	if s.counts == nil {
+		s.counts = make(map[string]int)
 	}
-
-	return "", nil
+	s.counts[reason]++
 }
 
-func detectRustFramework(componentPath string) (string, error) {
-	cargoPath := filepath.Join(componentPath, "Cargo.toml")
-	if _, err := os.Stat(cargoPath); err == nil {
-		content, err := os.ReadFile(cargoPath)
-		if err != nil {
-			return "", err
-		}
+func (s *skippedFiles) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		contentStr := string(content)
-		for framework, patterns := range frameworkPatterns {
-			for _, pattern := range patterns {
-				if strings.Contains(contentStr, pattern) {
-					return framework, nil
-				}
-			}
+	var parts []string
+	for _, reason := range []string{skipTooLarge, skipBinary, skipVendored, skipGenerated, skipDocumentation} {
+		if count := s.counts[reason]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, reason))
 		}
 	}
-
-	return "", nil
+	return strings.Join(parts, ", ")
 }
 
-func detectDotNetFramework(componentPath string) (string, error) {
-	pattern := filepath.Join(componentPath, "*.csproj")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return "", err
+func GetPrimaryLanguage(stats map[string]float64) string {
+	if len(stats) == 0 {
+		return ""
 	}
 
-	for _, match := range matches {
-		content, err := os.ReadFile(match)
-		if err != nil {
-			continue
-		}
+	var maxLang string
+	var maxPercentage float64
 
-		contentStr := string(content)
-		for framework, patterns := range frameworkPatterns {
-			for _, pattern := range patterns {
-				if strings.Contains(contentStr, pattern) {
-					return framework, nil
-				}
-			}
+	for lang, percentage := range stats {
+		if percentage > maxPercentage || (percentage == maxPercentage && lang < maxLang) {
+			maxPercentage = percentage
+			maxLang = lang
 		}
 	}
 
-	return "", nil
+	return maxLang
 }
 
-func detectPHPFramework(componentPath string) (string, error) {
-	composerPath := filepath.Join(componentPath, "composer.json")
-	if _, err := os.Stat(composerPath); err == nil {
-		data, err := os.ReadFile(composerPath)
-		if err != nil {
-			return "", err
-		}
-
-		var composer struct {
-			Require    map[string]string `json:"require"`
-			RequireDev map[string]string `json:"require-dev"`
-		}
+func DetectFrameworks(fsys fs.FS, dir string, primaryLang string) (string, error) {
+	candidates, _, err := newRepositoryFS("", fsys).frameworkCandidates(dir, primaryLang)
+	if err != nil || len(candidates) == 0 {
+		return "", err
+	}
+	return candidates[0].Name, nil
+}
 
-		if err := json.Unmarshal(data, &composer); err != nil {
-			return "", err
+// frameworkCandidates returns the frameworks for the language with evidence in
+// the component directory, by decreasing confidence. Earlier rules win ties.
+func (r *repository) frameworkCandidates(dir string, primaryLang string) ([]types.FrameworkCandidate, []types.Evidence, error) {
+	var applicable []*rules.Rule
+	needImports := false
+	for i := range r.rules.Frameworks {
+		rule := &r.rules.Frameworks[i]
+		if rule.MatchesLanguage(primaryLang) {
+			applicable = append(applicable, rule)
+			needImports = needImports || len(rule.Imports) > 0
 		}
+	}
 
-		allDeps := make(map[string]string)
-		for pkg, version := range composer.Require {
-			allDeps[pkg] = version
+	var imports []sourceImport
+	if needImports {
+		var err error
+		if imports, err = r.sourceImports(dir); err != nil {
+			return nil, nil, err
 		}
-		for pkg, version := range composer.RequireDev {
-			allDeps[pkg] = version
-		}
-
-		return detectFrameworkFromDependencies(allDeps), nil
 	}
 
-	return "", nil
-}
-
-func detectRubyFramework(componentPath string) (string, error) {
-	gemfilePath := filepath.Join(componentPath, "Gemfile")
-	if _, err := os.Stat(gemfilePath); err == nil {
-		content, err := os.ReadFile(gemfilePath)
+	var candidates []types.FrameworkCandidate
+	var evidence []types.Evidence
+	for _, rule := range applicable {
+		match, err := r.matchRule(dir, rule, evidenceFramework, imports)
 		if err != nil {
-			return "", err
+			return nil, nil, err
 		}
-
-		contentStr := string(content)
-		for framework, patterns := range frameworkPatterns {
-			for _, pattern := range patterns {
-				if strings.Contains(contentStr, pattern) {
-					return framework, nil
-				}
-			}
+		if confidence := match.confidence(); confidence > 0 {
+			candidates = append(candidates, types.FrameworkCandidate{Name: rule.Name, Confidence: confidence})
+			evidence = append(evidence, match.evidence()...)
 		}
 	}
 
-	return "", nil
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, evidence, nil
 }
 
-func detectFrameworkFromDependencies(deps map[string]string) string {
-	scores := make(map[string]int)
-
-	for framework, patterns := range frameworkPatterns {
-		for _, pattern := range patterns {
-			if _, exists := deps[pattern]; exists {
-				scores[framework]++
-			}
-		}
+func shouldSkipFile(fileName string) bool {
+	if strings.HasPrefix(fileName, ".") && fileName != ".env" && !strings.HasPrefix(fileName, ".env.") {
+		return true
 	}
-
-	if len(scores) == 0 {
-		return ""
+	
+	skipExtensions := []string{
+		".exe", ".dll", ".so", ".dylib", ".a", ".lib",
+		".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".ico",
+		".mp4", ".mp3", ".wav", ".avi", ".mov",
+		".zip", ".tar", ".gz", ".7z", ".rar",
+		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
 	}
-
-	var frameworks []string
-	for framework := range scores {
-		frameworks = append(frameworks, framework)
+	
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, skipExt := range skipExtensions {
+		if ext == skipExt {
+			return true
+		}
 	}
-
-	sort.Slice(frameworks, func(i, j int) bool {
-		return scores[frameworks[i]] > scores[frameworks[j]]
-	})
-
-	return frameworks[0]
+	
+	return false
 }
 
-func DetectDevelopmentTools(componentPath string) ([]string, error) {
-	var tools []string
-
-	packageJsonPath := filepath.Join(componentPath, "package.json")
-	if _, err := os.Stat(packageJsonPath); err == nil {
-		data, err := os.ReadFile(packageJsonPath)
-		if err == nil {
-			var packageJson struct {
-				DevDependencies map[string]string `json:"devDependencies"`
-				Scripts         map[string]string `json:"scripts"`
-			}
+func DetectDevelopmentTools(fsys fs.FS, dir string) ([]string, error) {
+	component := &types.Component{}
+	err := newRepositoryFS("", fsys).developmentTools(dir, component)
+	return component.DevelopmentTools, err
+}
 
-			if json.Unmarshal(data, &packageJson) == nil {
-				if _, exists := packageJson.DevDependencies["eslint"]; exists {
-					tools = append(tools, "ESLint")
-				}
-				if _, exists := packageJson.DevDependencies["prettier"]; exists {
-					tools = append(tools, "Prettier")
-				}
-				if _, exists := packageJson.DevDependencies["jest"]; exists {
-					tools = append(tools, "Jest")
-				}
-				if _, exists := packageJson.DevDependencies["typescript"]; exists {
-					tools = append(tools, "TypeScript")
-				}
-			}
+func (r *repository) developmentTools(dir string, component *types.Component) error {
+	for i := range r.rules.Tools {
+		match, err := r.matchRule(dir, &r.rules.Tools[i], evidenceTool, nil)
+		if err != nil {
+			return err
 		}
-	}
-
-	if _, err := os.Stat(filepath.Join(componentPath, "requirements-dev.txt")); err == nil {
-		tools = append(tools, "pip-tools")
-	}
-
-	if _, err := os.Stat(filepath.Join(componentPath, "pyproject.toml")); err == nil {
-		content, err := os.ReadFile(filepath.Join(componentPath, "pyproject.toml"))
-		if err == nil {
-			contentStr := string(content)
-			if strings.Contains(contentStr, "black") {
-				tools = append(tools, "Black")
-			}
-			if strings.Contains(contentStr, "flake8") {
-				tools = append(tools, "Flake8")
-			}
-			if strings.Contains(contentStr, "pytest") {
-				tools = append(tools, "pytest")
-			}
+		if evidence := match.evidence(); len(evidence) > 0 {
+			component.DevelopmentTools = append(component.DevelopmentTools, r.rules.Tools[i].Name)
+			addEvidence(component, evidence...)
 		}
 	}
 
-	return tools, nil
-}
\ No newline at end of file
+	return nil
+}
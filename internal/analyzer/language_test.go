@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestGetLanguageStatsRespectsIgnoreFiles(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		".gitignore":              "out/\n*.generated.go\n",
+		".replyzerignore":         "/fixtures\n",
+		"main.go":                 "package main\n\nfunc main() {}\n",
+		"build/tools.go":          "package build\n",
+		"out/bundle.js":           "console.log('generated output that should not be counted');\n",
+		"models.generated.go":     "package main\n\nvar generated = true\n",
+		"fixtures/sample.py":      "print('fixture')\n",
+		"node_modules/x/index.js": "module.exports = {};\n",
+	})
+
+	stats, err := GetLanguageStats(fsys, ".")
+	if err != nil {
+		t.Fatalf("GetLanguageStats() error = %v", err)
+	}
+
+	if len(stats) != 1 || stats["Go"] != 100 {
+		t.Errorf("Expected only Go to be counted, got %v", stats)
+	}
+}
+
+func TestLanguageStatsSkipsFiles(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"dump.sql":          strings.Repeat("INSERT INTO t VALUES (1);\n", 100),
+		"checkpoint":        "PK\x00\x01" + strings.Repeat("\x00", 64),
+		"vendor/lib/lib.go": "package lib\n",
+		"docs/guide.md":     "# Guide\n",
+		"package-lock.json": `{"lockfileVersion": 3}`,
+		"api.go":            "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage main\n",
+	})
+
+	r := newRepositoryFS("", fsys)
+	r.maxFileSize = 1024
+	stats, err := r.languageStats(".")
+	if err != nil {
+		t.Fatalf("languageStats() error = %v", err)
+	}
+
+	if len(stats) != 1 || stats["Go"] != 100 {
+		t.Errorf("Expected only main.go to be counted, got %v", stats)
+	}
+	if got, want := r.skipped.summary(), "1 too large, 1 binary, 1 vendored, 2 generated, 1 documentation"; got != want {
+		t.Errorf("summary() = %q, want %q", got, want)
+	}
+
+	r = newRepositoryFS("", fsys)
+	r.maxFileSize = 0
+	if stats, _ := r.languageStats("."); stats["SQL"] == 0 {
+		t.Errorf("Expected no size limit with 0, got %v", stats)
+	}
+}
+
+func TestLanguageStatsHonorsGitattributes(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		".gitattributes":        "api/*.pb.go linguist-generated\nstatic/** linguist-vendored\n*.tmpl linguist-language=html\n",
+		"go.mod":                "module example.com/app\n",
+		"main.go":               "package main\n\nfunc main() {}\n",
+		"api/user.pb.go":        strings.Repeat("package api\n", 200),
+		"static/bundle.js":      strings.Repeat("var x = 1;\n", 200),
+		"views/index.tmpl":      "<html></html>\n",
+		"vendor/.gitattributes": "ours/** -linguist-vendored\n",
+		"vendor/ours/helper.py": "print('ours')\n",
+		"vendor/other/lib.py":   "print('theirs')\n",
+		"guides/.gitattributes": "*.go linguist-documentation\n",
+		"guides/example.go":     strings.Repeat("package guides\n", 200),
+	})
+
+	result, err := AnalyzeFS(context.Background(), fsys, "app", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	component := result.Components[0]
+	var languages []string
+	for language := range component.LanguageStats {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	if want := []string{"Go", "Go Module", "HTML", "Python"}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("Expected languages %v, got %v", want, component.LanguageStats)
+	}
+	if component.PrimaryLanguage != "Go" {
+		t.Errorf("Expected Go to be the primary language, got %s", component.PrimaryLanguage)
+	}
+}
+
+func TestStatsModes(t *testing.T) {
+	// The JavaScript is the largest in bytes but not minified, which would
+	// make it generated.
+	fsys := mapFS(map[string]string{
+		"go.mod":    "module example.com/app\n",
+		"main.go":   "package main\n\n// main runs the server.\nfunc main() {\n\tserve()\n}\n",
+		"server.go": "package main\n\nfunc serve() {\n" + strings.Repeat("\tx := 1\n\t_ = x\n", 15) + "}\n",
+		"app.js":    strings.Repeat("var value = '"+strings.Repeat("a", 80)+"';\n", 20),
+	})
+
+	counts, err := newRepositoryFS("", fsys).languageCounts(".")
+	if err != nil {
+		t.Fatalf("languageCounts() error = %v", err)
+	}
+
+	want := map[string]types.LanguageCount{
+		"Go":         {Files: 2, Bytes: 320, Lines: 40, Code: 37, Comments: 1, Blank: 2},
+		"Go Module":  {Files: 1, Bytes: 23, Lines: 1, Code: 1},
+		"JavaScript": {Files: 1, Bytes: 1920, Lines: 20, Code: 20},
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("languageCounts() = %+v, want %+v", counts, want)
+	}
+
+	for mode, primary := range map[string]string{
+		StatsModeBytes: "JavaScript",
+		StatsModeLines: "Go",
+		StatsModeFiles: "Go",
+	} {
+		result, err := AnalyzeFS(context.Background(), fsys, "app", &types.AnalysisOptions{StatsMode: mode})
+		if err != nil {
+			t.Fatalf("AnalyzeFS() error = %v", err)
+		}
+		if got := result.Components[0].PrimaryLanguage; got != primary {
+			t.Errorf("Expected %s to be the primary language by %s, got %s (%v)", primary, mode, got, result.Components[0].LanguageStats)
+		}
+	}
+
+	stats := languageShares(want, StatsModeLines)
+	if stats["Go"] != 3700.0/58 || stats["JavaScript"] != 2000.0/58 {
+		t.Errorf("Unexpected shares of lines of code: %v", stats)
+	}
+
+	if _, err := AnalyzeFS(context.Background(), fsys, "app", &types.AnalysisOptions{StatsMode: "tokens"}); err == nil {
+		t.Error("Expected an error for an unknown stats mode")
+	}
+}
+
+func TestDiscoverRespectsIgnoreFiles(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		".gitignore":                 "examples/\n",
+		"package.json":               `{"name": "app"}`,
+		"examples/demo/package.json": `{"name": "demo"}`,
+		"target-service/go.mod":      "module example.com/target\n",
+		"build-tools/package.json":   `{"name": "build-tools"}`,
+		".cache/tool/package.json":   `{"name": "cache"}`,
+	})
+
+	structure, err := DiscoverProjectStructure(fsys, "repo")
+	if err != nil {
+		t.Fatalf("DiscoverProjectStructure() error = %v", err)
+	}
+
+	for _, expected := range []string{"", "target-service", "build-tools"} {
+		if findComponent(structure, expected) == nil {
+			t.Errorf("Expected component %q to be discovered", expected)
+		}
+	}
+	for _, ignored := range []string{"examples/demo", ".cache/tool"} {
+		if findComponent(structure, ignored) != nil {
+			t.Errorf("Expected component %q to be ignored", ignored)
+		}
+	}
+}
+
+func TestFrameworkCandidates(t *testing.T) {
+	packageJSON := &fstest.MapFile{Data: []byte(`{"dependencies": {"next": "14.0.0", "react": "18.2.0", "react-dom": "18.2.0"}}`)}
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  []types.FrameworkCandidate
+	}{
+		{
+			name: "config file and imports",
+			files: fstest.MapFS{
+				"web/package.json":   packageJSON,
+				"web/next.config.js": &fstest.MapFile{Data: []byte("module.exports = {};\n")},
+				"web/app/page.js":    &fstest.MapFile{Data: []byte("import Link from 'next/link';\nimport { useState } from 'react';\n")},
+			},
+			want: []types.FrameworkCandidate{{Name: "Next.js", Confidence: 1}, {Name: "React", Confidence: 0.75}},
+		},
+		{
+			name:  "dependencies only tie",
+			files: fstest.MapFS{"web/package.json": packageJSON},
+			want:  []types.FrameworkCandidate{{Name: "Next.js", Confidence: 0.6}, {Name: "React", Confidence: 0.6}},
+		},
+		{
+			name: "config file only",
+			files: fstest.MapFS{
+				"web/angular.json": &fstest.MapFile{Data: []byte("{}")},
+			},
+			want: []types.FrameworkCandidate{{Name: "Angular", Confidence: 0.25}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for run := 0; run < 10; run++ {
+				candidates, _, err := newRepositoryFS("", tt.files).frameworkCandidates("web", "TypeScript")
+				if err != nil {
+					t.Fatalf("frameworkCandidates() error = %v", err)
+				}
+				if !reflect.DeepEqual(candidates, tt.want) {
+					t.Fatalf("frameworkCandidates() = %v, want %v", candidates, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+type commentSyntax struct {
+	line       []string
+	blockStart string
+	blockEnd   string
+}
+
+var (
+	cStyleComments = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashComments   = commentSyntax{line: []string{"#"}}
+	markupComments = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+)
+
+// Lines of languages not listed here are counted as code or blank.
+var commentSyntaxes = func() map[string]commentSyntax {
+	syntaxes := make(map[string]commentSyntax)
+	for _, group := range []struct {
+		syntax    commentSyntax
+		languages []string
+	}{
+		{cStyleComments, []string{
+			"C", "C++", "C#", "Objective-C", "Go", "Rust", "Java", "Kotlin", "Scala", "Groovy", "Swift", "Dart",
+			"JavaScript", "TypeScript", "TSX", "JSX", "Vue", "Svelte", "Less", "SCSS", "Protocol Buffer",
+			"JSON with Comments",
+		}},
+		{hashComments, []string{
+			"Python", "Shell", "Perl", "R", "YAML", "TOML", "Dockerfile", "Makefile", "Elixir", "CoffeeScript",
+		}},
+		{markupComments, []string{"HTML", "XML", "Markdown"}},
+		{commentSyntax{blockStart: "/*", blockEnd: "*/"}, []string{"CSS"}},
+		{commentSyntax{line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"}, []string{"PHP", "HCL"}},
+		{commentSyntax{line: []string{"--"}, blockStart: "/*", blockEnd: "*/"}, []string{"SQL", "PLpgSQL", "PLSQL", "TSQL"}},
+		{commentSyntax{line: []string{"--"}, blockStart: "{-", blockEnd: "-}"}, []string{"Haskell", "Elm"}},
+		{commentSyntax{line: []string{"--"}, blockStart: "--[[", blockEnd: "]]"}, []string{"Lua"}},
+		{commentSyntax{line: []string{"#"}, blockStart: "=begin", blockEnd: "=end"}, []string{"Ruby"}},
+		{commentSyntax{line: []string{"#"}, blockStart: "<#", blockEnd: "#>"}, []string{"PowerShell"}},
+		{commentSyntax{line: []string{";"}}, []string{"Clojure", "Emacs Lisp", "Common Lisp"}},
+		{commentSyntax{line: []string{"%"}}, []string{"Erlang", "TeX"}},
+		{commentSyntax{line: []string{"'"}}, []string{"Visual Basic .NET"}},
+	} {
+		for _, language := range group.languages {
+			syntaxes[language] = group.syntax
+		}
+	}
+	return syntaxes
+}()
+
+type lineCounts struct {
+	Lines    int `json:"lines"`
+	Code     int `json:"code"`
+	Comments int `json:"comments"`
+	Blank    int `json:"blank"`
+}
+
+// Comments are only recognized at the start of a line, so that markers in
+// string literals such as "src/**/*.js" are not mistaken for comments.
+func countLines(content []byte, language string) lineCounts {
+	syntax := commentSyntaxes[language]
+
+	var counts lineCounts
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		counts.Lines++
+
+		switch {
+		case line == "":
+			counts.Blank++
+		case inBlock || syntax.blockStart != "" && strings.HasPrefix(line, syntax.blockStart):
+			if !inBlock {
+				line = line[len(syntax.blockStart):]
+				inBlock = true
+			}
+			counts.Comments++
+			if i := strings.Index(line, syntax.blockEnd); i >= 0 {
+				inBlock = false
+				if rest := strings.TrimSpace(line[i+len(syntax.blockEnd):]); rest != "" && !syntax.isComment(rest) {
+					counts.Comments--
+					counts.Code++
+				}
+			}
+		case syntax.isComment(line):
+			counts.Comments++
+		default:
+			counts.Code++
+		}
+	}
+
+	return counts
+}
+
+func (s commentSyntax) isComment(line string) bool {
+	for _, prefix := range s.line {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package analyzer
+
+import "testing"
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     lineCounts
+	}{
+		{"empty", "Go", "", lineCounts{}},
+		{"no trailing newline", "Go", "package main\n\nfunc main() {}", lineCounts{Lines: 3, Code: 2, Blank: 1}},
+		{
+			"go comments", "Go",
+			"// Package main.\npackage main\n\n/*\n  block\n\n*/\nvar x = 1 // trailing\n/* one line */ var y = 2\n",
+			lineCounts{Lines: 9, Code: 3, Comments: 4, Blank: 2},
+		},
+		{"block end followed by code", "C", "/* a\n b */ int x;\n", lineCounts{Lines: 2, Code: 1, Comments: 1}},
+		{"markers in strings", "JavaScript", "const glob = 'src/**/*.js';\nconst url = 'http://x';\n", lineCounts{Lines: 2, Code: 2}},
+		{"python", "Python", "#!/usr/bin/env python\nimport os\n  # indented\n", lineCounts{Lines: 3, Code: 1, Comments: 2}},
+		{"sql", "SQL", "-- schema\nCREATE TABLE t (id int);\n", lineCounts{Lines: 2, Code: 1, Comments: 1}},
+		{"ruby block", "Ruby", "=begin\ndocs\n=end\nputs 1\n", lineCounts{Lines: 4, Code: 1, Comments: 3}},
+		{"html", "HTML", "<!-- note -->\n<p>hi</p>\n", lineCounts{Lines: 2, Code: 1, Comments: 1}},
+		{"no comment syntax", "JSON", "{\n  \"#\": 1\n}\n", lineCounts{Lines: 3, Code: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countLines([]byte(tt.content), tt.language); got != tt.want {
+				t.Errorf("countLines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
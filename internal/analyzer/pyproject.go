@@ -0,0 +1,101 @@
+package analyzer
+
+import "github.com/BurntSushi/toml"
+
+type pyproject struct {
+	Project struct {
+		RequiresPython       string              `toml:"requires-python"`
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	DependencyGroups map[string][]any `toml:"dependency-groups"` // requirements and {include-group = "..."} tables
+	Tool             struct {
+		Poetry struct {
+			Dependencies    map[string]any `toml:"dependencies"` // "python" is the required Python version
+			DevDependencies map[string]any `toml:"dev-dependencies"`
+			Group           map[string]struct {
+				Dependencies map[string]any `toml:"dependencies"`
+			} `toml:"group"`
+		} `toml:"poetry"`
+		PDM struct {
+			DevDependencies map[string][]string `toml:"dev-dependencies"`
+		} `toml:"pdm"`
+		Hatch struct {
+			Envs map[string]struct {
+				Dependencies      []string `toml:"dependencies"`
+				ExtraDependencies []string `toml:"extra-dependencies"`
+			} `toml:"envs"`
+		} `toml:"hatch"`
+	} `toml:"tool"`
+}
+
+func parsePyproject(content []byte) (*pyproject, error) {
+	var project pyproject
+	if _, err := toml.Decode(string(content), &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (p *pyproject) dependencies() map[string]bool {
+	declared := make(map[string]bool)
+	addRequirements := func(requirements []string) {
+		for _, requirement := range requirements {
+			if name := requirementName(requirement); name != "" {
+				declared[name] = true
+			}
+		}
+	}
+	addPoetry := func(dependencies map[string]any) {
+		for name := range dependencies {
+			if name != "python" {
+				declared[name] = true
+			}
+		}
+	}
+
+	addRequirements(p.Project.Dependencies)
+	for _, requirements := range p.Project.OptionalDependencies {
+		addRequirements(requirements)
+	}
+	for _, group := range p.DependencyGroups {
+		for _, item := range group {
+			if requirement, ok := item.(string); ok {
+				addRequirements([]string{requirement})
+			}
+		}
+	}
+
+	addPoetry(p.Tool.Poetry.Dependencies)
+	addPoetry(p.Tool.Poetry.DevDependencies)
+	for _, group := range p.Tool.Poetry.Group {
+		addPoetry(group.Dependencies)
+	}
+
+	for _, requirements := range p.Tool.PDM.DevDependencies {
+		addRequirements(requirements)
+	}
+	for _, env := range p.Tool.Hatch.Envs {
+		addRequirements(env.Dependencies)
+		addRequirements(env.ExtraDependencies)
+	}
+
+	return declared
+}
+
+// pythonRequirement returns the required Python version and the key it was
+// read from.
+func (p *pyproject) pythonRequirement() (string, string) {
+	if p.Project.RequiresPython != "" {
+		return p.Project.RequiresPython, "project.requires-python"
+	}
+	switch python := p.Tool.Poetry.Dependencies["python"].(type) {
+	case string:
+		return python, "tool.poetry.dependencies.python"
+	case map[string]any:
+		if version, ok := python["version"].(string); ok {
+			return version, "tool.poetry.dependencies.python"
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestPyprojectDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]bool
+	}{
+		{
+			name: "PEP 621",
+			content: `[project]
+name = "api"
+requires-python = ">=3.11"
+dependencies = [
+    "fastapi>=0.110",
+    "uvicorn[standard] ; python_version >= '3.8'",
+]
+
+[project.optional-dependencies]
+test = ["pytest~=8.0"]
+
+[dependency-groups]
+lint = ["ruff", {include-group = "test"}]
+`,
+			want: map[string]bool{"fastapi": true, "uvicorn": true, "pytest": true, "ruff": true},
+		},
+		{
+			name: "Poetry",
+			content: `[tool.poetry.dependencies]
+python = "^3.10"
+Django = "^5.0"
+celery = { version = "^5.3", extras = ["redis"] }
+
+[tool.poetry.dev-dependencies]
+black = "^24.0"
+
+[tool.poetry.group.test.dependencies]
+pytest = "^8.0"
+`,
+			want: map[string]bool{"Django": true, "celery": true, "black": true, "pytest": true},
+		},
+		{
+			name: "PDM and Hatch",
+			content: `[tool.pdm.dev-dependencies]
+lint = ["flake8>=7"]
+
+[tool.hatch.envs.default]
+dependencies = ["pytest"]
+
+[tool.hatch.envs.docs]
+extra-dependencies = ["mkdocs"]
+`,
+			want: map[string]bool{"flake8": true, "pytest": true, "mkdocs": true},
+		},
+		{
+			name:    "invalid",
+			content: "[project\n",
+			want:    map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manifestDependencies("pyproject.toml", []byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("manifestDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPyprojectPythonVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{"PEP 621", "[project]\nname = \"api\"\nrequires-python = \">=3.11\"\n", map[string]string{"python": ">=3.11"}},
+		{"Poetry", "[tool.poetry.dependencies]\npython = \"^3.10\"\n", map[string]string{"python": "^3.10"}},
+		{"comment", "[project]\n# requires-python = \">=3.8\"\nname = \"api\"\n", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"pyproject.toml": &fstest.MapFile{Data: []byte(tt.content)}}
+			got, err := ExtractVersionRequirements(fsys, ".")
+			if err != nil {
+				t.Fatalf("ExtractVersionRequirements() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractVersionRequirements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPyprojectTools(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pyproject.toml": &fstest.MapFile{Data: []byte(`[project]
+name = "api"
+requires-python = ">=3.11"
+# formatted with black
+dependencies = ["pytest-asyncio"]
+
+[tool.flake8]
+max-line-length = 100
+`)},
+		"main.py": &fstest.MapFile{Data: []byte("print('hello')\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "api", &types.AnalysisOptions{Evidence: true})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	component := result.Components[0]
+
+	if want := []string{"Flake8"}; !reflect.DeepEqual(component.DevelopmentTools, want) {
+		t.Errorf("DevelopmentTools = %v, want %v", component.DevelopmentTools, want)
+	}
+	for _, e := range component.Evidence {
+		if e.Kind == "version" && e.Name == "python" && (e.Line != 3 || e.Match != "project.requires-python") {
+			t.Errorf("Unexpected python evidence %+v", e)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func isRequirementsFile(name string) bool {
+	return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+}
+
+// parseRequirements returns the packages a requirements file declares and
+// the files it includes with -r. Constraints files (-c) are not followed.
+func parseRequirements(content []byte) (names, includes []string) {
+	text := strings.ReplaceAll(string(content), "\\\n", " ")
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "-") {
+			option, value := splitRequirementOption(line)
+			switch option {
+			case "-r", "--requirement":
+				if value != "" {
+					includes = append(includes, value)
+				}
+			case "-e", "--editable":
+				if name := eggName(value); name != "" {
+					names = append(names, name)
+				}
+			}
+			continue
+		}
+
+		if name := eggName(line); name != "" {
+			names = append(names, name)
+			continue
+		}
+		if strings.Contains(line, "://") && !strings.Contains(line, "@") ||
+			strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/") {
+			continue
+		}
+		if name := requirementName(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, includes
+}
+
+// requirementName returns the package name of a PEP 508 requirement.
+func requirementName(requirement string) string {
+	requirement = strings.TrimSpace(requirement)
+	end := strings.IndexFunc(requirement, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+	})
+	if end >= 0 {
+		requirement = requirement[:end]
+	}
+	return requirement
+}
+
+var packageNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePackageName returns the PEP 503 normalized form of a name.
+func normalizePackageName(name string) string {
+	return packageNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+func splitRequirementOption(line string) (string, string) {
+	if strings.HasPrefix(line, "--") {
+		option, value, found := strings.Cut(line, "=")
+		if !found {
+			option, value, _ = strings.Cut(line, " ")
+		}
+		return option, strings.TrimSpace(value)
+	}
+	if len(line) < 2 {
+		return line, ""
+	}
+	return line[:2], strings.TrimSpace(line[2:])
+}
+
+func eggName(requirement string) string {
+	_, egg, found := strings.Cut(requirement, "#egg=")
+	if !found {
+		return ""
+	}
+	return requirementName(egg)
+}
+
+type pipfile struct {
+	Packages    map[string]any `toml:"packages"`
+	DevPackages map[string]any `toml:"dev-packages"`
+	Requires    struct {
+		PythonVersion     string `toml:"python_version"`
+		PythonFullVersion string `toml:"python_full_version"`
+	} `toml:"requires"`
+}
+
+func parsePipfile(content []byte) (*pipfile, error) {
+	var file pipfile
+	if _, err := toml.Decode(string(content), &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (p *pipfile) pythonRequirement() (string, string) {
+	if p.Requires.PythonFullVersion != "" {
+		return p.Requires.PythonFullVersion, "requires.python_full_version"
+	}
+	if p.Requires.PythonVersion != "" {
+		return p.Requires.PythonVersion, "requires.python_version"
+	}
+	return "", ""
+}
+
+type pipfileLock struct {
+	Meta struct {
+		Requires struct {
+			PythonVersion     string `json:"python_version"`
+			PythonFullVersion string `json:"python_full_version"`
+		} `json:"requires"`
+	} `json:"_meta"`
+	Default map[string]json.RawMessage `json:"default"`
+	Develop map[string]json.RawMessage `json:"develop"`
+}
+
+func pipfileLockPackages(content []byte) []string {
+	var lock pipfileLock
+	if json.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+
+	var names []string
+	for name := range lock.Default {
+		names = append(names, name)
+	}
+	for name := range lock.Develop {
+		names = append(names, name)
+	}
+	return names
+}
+
+// pipfileLockPython returns the Python version copied from the [requires]
+// table of the Pipfile, and its key.
+func pipfileLockPython(content []byte) (string, string) {
+	var lock pipfileLock
+	if json.Unmarshal(content, &lock) != nil {
+		return "", ""
+	}
+	if lock.Meta.Requires.PythonFullVersion != "" {
+		return lock.Meta.Requires.PythonFullVersion, "python_full_version"
+	}
+	if lock.Meta.Requires.PythonVersion != "" {
+		return lock.Meta.Requires.PythonVersion, "python_version"
+	}
+	return "", ""
+}
+
+func uvLockPython(content []byte) string {
+	var lock struct {
+		RequiresPython string `toml:"requires-python"`
+	}
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return ""
+	}
+	return lock.RequiresPython
+}
+
+// lockPackages returns the [[package]] names of a uv.lock or poetry.lock.
+func lockPackages(content []byte) []string {
+	var lock struct {
+		Package []struct {
+			Name string `toml:"name"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, pkg := range lock.Package {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+	return names
+}
+
+type setupConfig struct {
+	Requires       []string
+	PythonRequires string
+}
+
+func parseSetupConfig(content []byte) setupConfig {
+	var config setupConfig
+	section, key := "", ""
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		// A list is either continued on indented lines, one value per line,
+		// or written after the key, separated by semicolons.
+		var values []string
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			section, key = strings.Trim(trimmed, "[]"), ""
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			values = []string{trimmed}
+		default:
+			name, value, found := strings.Cut(trimmed, "=")
+			if !found {
+				key = ""
+				continue
+			}
+			key, values = strings.TrimSpace(name), strings.Split(value, ";")
+		}
+
+		switch {
+		case section == "options" && key == "python_requires":
+			config.PythonRequires = strings.TrimSpace(values[0])
+		case section == "options" && key == "install_requires", section == "options.extras_require" && key != "":
+			for _, value := range values {
+				if name := requirementName(value); name != "" {
+					config.Requires = append(config.Requires, name)
+				}
+			}
+		}
+	}
+	return config
+}
+
+var (
+	setupRequiresRegex = regexp.MustCompile(`(?:install_requires|tests_require)\s*=\s*\[([^\]]*)\]`)
+	setupPythonRegex   = regexp.MustCompile(`python_requires\s*=\s*["']([^"']+)["']`)
+)
+
+// parseSetupScript reads the requirements a setup.py passes as literals.
+// Values computed at run time are ignored.
+func parseSetupScript(content []byte) setupConfig {
+	var config setupConfig
+	for _, list := range setupRequiresRegex.FindAllStringSubmatch(string(content), -1) {
+		for _, match := range quotedStringRegex.FindAllStringSubmatch(list[1], -1) {
+			if name := requirementName(match[1] + match[2]); name != "" {
+				config.Requires = append(config.Requires, name)
+			}
+		}
+	}
+	if matches := setupPythonRegex.FindStringSubmatch(string(content)); matches != nil {
+		config.PythonRequires = matches[1]
+	}
+	return config
+}
+
+func isCondaEnvironment(name string) bool {
+	return name == "environment.yml" || name == "environment.yaml"
+}
+
+// Dependencies are conda match specs such as "conda-forge::numpy>=1.26",
+// and a {pip: [...]} entry with pip requirements.
+type condaEnvironment struct {
+	Dependencies []any `yaml:"dependencies"`
+}
+
+func parseCondaEnvironment(content []byte) (names []string, python string) {
+	var env condaEnvironment
+	if yaml.Unmarshal(content, &env) != nil {
+		return nil, ""
+	}
+
+	for _, dependency := range env.Dependencies {
+		switch dependency := dependency.(type) {
+		case string:
+			spec := dependency
+			if i := strings.LastIndex(spec, "::"); i >= 0 {
+				spec = spec[i+2:]
+			}
+			name := requirementName(spec)
+			if name == "python" {
+				python = condaVersion(strings.TrimSpace(spec[len(name):]))
+			} else if name != "" {
+				names = append(names, name)
+			}
+		case map[string]any:
+			requirements, _ := dependency["pip"].([]any)
+			for _, requirement := range requirements {
+				if requirement, ok := requirement.(string); ok {
+					if name := requirementName(requirement); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+	return names, python
+}
+
+// condaVersion strips the build string from a conda version: "=3.11" and
+// "3.11=h123_0" are 3.11.
+func condaVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if strings.HasPrefix(version, "=") && !strings.HasPrefix(version, "==") {
+		version = version[1:]
+	}
+	if i := strings.Index(version, "="); i > 0 && !strings.ContainsAny(version[:i], "<>!~=") {
+		version = version[:i]
+	}
+	return version
+}
+
+func isPythonManifest(name string) bool {
+	switch name {
+	case "pyproject.toml", "Pipfile", "Pipfile.lock", "uv.lock", "poetry.lock", "setup.cfg", "setup.py":
+		return true
+	}
+	return isRequirementsFile(name) || isCondaEnvironment(name)
+}
+
+// pythonManifestDependencies returns false if name is not a Python manifest.
+func pythonManifestDependencies(name string, content []byte) ([]string, bool) {
+	switch {
+	case isRequirementsFile(name):
+		names, _ := parseRequirements(content)
+		return names, true
+	case name == "Pipfile":
+		file, err := parsePipfile(content)
+		if err != nil {
+			return nil, true
+		}
+		var names []string
+		for pkg := range file.Packages {
+			names = append(names, pkg)
+		}
+		for pkg := range file.DevPackages {
+			names = append(names, pkg)
+		}
+		return names, true
+	case name == "Pipfile.lock":
+		return pipfileLockPackages(content), true
+	case name == "uv.lock", name == "poetry.lock":
+		return lockPackages(content), true
+	case name == "setup.cfg":
+		return parseSetupConfig(content).Requires, true
+	case name == "setup.py":
+		return parseSetupScript(content).Requires, true
+	case isCondaEnvironment(name):
+		names, _ := parseCondaEnvironment(content)
+		return names, true
+	}
+	return nil, false
+}
+
+// requirementIncludes returns the files manifest includes with -r, relative
+// to the repository root. Includes outside the repository are left out.
+func (r *repository) requirementIncludes(manifest string) ([]string, error) {
+	var includes []string
+	err := r.readCached(manifest, cacheIncludes, &includes, func(content []byte) {
+		_, relative := parseRequirements(content)
+		includes = make([]string, 0, len(relative))
+		for _, include := range relative {
+			if include = path.Join(path.Dir(manifest), include); fs.ValidPath(include) {
+				includes = append(includes, include)
+			}
+		}
+	})
+	return includes, err
+}
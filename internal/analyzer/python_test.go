@@ -0,0 +1,379 @@
+package analyzer
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestParseRequirements(t *testing.T) {
+	content := []byte(`# web
+django>=4.2,<5
+Flask[async]~=2.0
+requests == 2.31.0  # pinned
+uvicorn[standard] ; python_version >= "3.8"
+celery \
+    >=5.3
+mypkg @ https://example.com/mypkg-1.0.tar.gz
+-r requirements/base.txt
+--requirement=dev.txt
+-c constraints.txt
+-e git+https://github.com/acme/tool.git#egg=acme-tool
+-e .
+--index-url https://pypi.example.com/simple
+https://example.com/archive.zip
+./vendor/local-package
+`)
+
+	names, includes := parseRequirements(content)
+	wantNames := []string{"django", "Flask", "requests", "uvicorn", "celery", "mypkg", "acme-tool"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("parseRequirements() names = %v, want %v", names, wantNames)
+	}
+	if wantIncludes := []string{"requirements/base.txt", "dev.txt"}; !reflect.DeepEqual(includes, wantIncludes) {
+		t.Errorf("parseRequirements() includes = %v, want %v", includes, wantIncludes)
+	}
+}
+
+func TestPythonManifestDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name: "Pipfile",
+			file: "Pipfile",
+			content: `[packages]
+django = "*"
+requests = {version = ">=2.31", extras = ["socks"]}
+
+[dev-packages]
+pytest = "*"
+
+[requires]
+python_version = "3.12"
+`,
+			want: []string{"django", "pytest", "requests"},
+		},
+		{
+			name:    "Pipfile.lock",
+			file:    "Pipfile.lock",
+			content: `{"_meta": {"requires": {"python_version": "3.12"}}, "default": {"flask": {"version": "==3.0.0"}}, "develop": {"black": {}}}`,
+			want:    []string{"black", "flask"},
+		},
+		{
+			name: "setup.cfg",
+			file: "setup.cfg",
+			content: `[metadata]
+name = api
+
+[options]
+python_requires = >=3.9
+install_requires =
+    fastapi>=0.110
+    pydantic[email]; python_version >= "3.9"
+
+[options.extras_require]
+test = pytest; coverage
+
+[flake8]
+max-line-length = 100
+`,
+			want: []string{"coverage", "fastapi", "pydantic", "pytest"},
+		},
+		{
+			name: "setup.py",
+			file: "setup.py",
+			content: `from setuptools import setup
+
+setup(
+    name="api",
+    python_requires=">=3.10",
+    install_requires=[
+        "flask>=3",
+        'gunicorn',
+    ],
+    tests_require=["pytest"],
+    extras_require={"dev": ["black"]},
+)
+`,
+			want: []string{"flask", "gunicorn", "pytest"},
+		},
+		{
+			name: "environment.yml",
+			file: "environment.yml",
+			content: `name: science
+channels: [conda-forge]
+dependencies:
+  - python=3.11
+  - conda-forge::numpy>=1.26
+  - pandas
+  - pip
+  - pip:
+      - django>=5
+`,
+			want: []string{"django", "numpy", "pandas", "pip"},
+		},
+		{
+			name: "uv.lock",
+			file: "uv.lock",
+			content: `version = 1
+requires-python = ">=3.12"
+
+[[package]]
+name = "fastapi"
+version = "0.110.0"
+
+[[package]]
+name = "starlette"
+version = "0.36.3"
+`,
+			want: []string{"fastapi", "starlette"},
+		},
+		{
+			name: "poetry.lock",
+			file: "poetry.lock",
+			content: `[[package]]
+name = "django"
+version = "5.0.3"
+
+[metadata]
+python-versions = "^3.10"
+`,
+			want: []string{"django"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, ok := pythonManifestDependencies(tt.file, []byte(tt.content))
+			if !ok {
+				t.Fatalf("Expected %s to be a Python manifest", tt.file)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("pythonManifestDependencies() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	if _, ok := pythonManifestDependencies("package.json", []byte("{}")); ok {
+		t.Error("Expected package.json not to be a Python manifest")
+	}
+}
+
+func TestNormalizePackageName(t *testing.T) {
+	tests := map[string]string{
+		"Django":          "django",
+		"PyTest":          "pytest",
+		"zope_interface":  "zope-interface",
+		"Ruamel.YAML":     "ruamel-yaml",
+		"friendly-._bard": "friendly-bard",
+	}
+	for name, want := range tests {
+		if got := normalizePackageName(name); got != want {
+			t.Errorf("normalizePackageName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPythonToolsMatchNormalizedNames(t *testing.T) {
+	files := map[string]string{
+		"requirements.txt": "PyTest>=8\n",
+		"Pipfile":          "[dev-packages]\nBlack = \"*\"\n",
+		"app.py":           strings.Repeat("print('hello')\n", 20),
+	}
+	result, err := AnalyzeFS(context.Background(), mapFS(files), "api", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	tools := result.Components[0].DevelopmentTools
+	for _, want := range []string{"pytest", "Black"} {
+		if !contains(tools, want) {
+			t.Errorf("Expected %s in %v", want, tools)
+		}
+	}
+}
+
+func TestPythonVersions(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  map[string]string
+	}{
+		{"setup.py", map[string]string{"setup.py": "setup(python_requires='>=3.8')\n"}, map[string]string{"python": ">=3.8"}},
+		{"setup.cfg", map[string]string{"setup.cfg": "[options]\npython_requires = >=3.9\n"}, map[string]string{"python": ">=3.9"}},
+		{"Pipfile", map[string]string{"Pipfile": "[requires]\npython_version = \"3.12\"\n"}, map[string]string{"python": "3.12"}},
+		{"conda", map[string]string{"environment.yml": "dependencies:\n  - python=3.11.8=h123_0\n  - numpy\n"}, map[string]string{"python": "3.11.8"}},
+		{"conda constraint", map[string]string{"environment.yaml": "dependencies:\n  - python>=3.10\n"}, map[string]string{"python": ">=3.10"}},
+		{"uv.lock", map[string]string{"uv.lock": "version = 1\nrequires-python = \">=3.11\"\n\n[[package]]\nname = \"fastapi\"\n"}, map[string]string{"python": ">=3.11"}},
+		{"Pipfile.lock", map[string]string{"Pipfile.lock": `{"_meta": {"requires": {"python_version": "3.12"}}, "default": {}}`}, map[string]string{"python": "3.12"}},
+		{"Pipfile.lock full version", map[string]string{"Pipfile.lock": `{"_meta": {"requires": {"python_version": "3.12", "python_full_version": "3.12.4"}}}`}, map[string]string{"python": "3.12.4"}},
+		{
+			"precedence",
+			map[string]string{
+				"setup.py":        "setup(python_requires='>=3.8')\n",
+				"pyproject.toml":  "[project]\nrequires-python = \">=3.10\"\n",
+				"environment.yml": "dependencies:\n  - python=3.11\n",
+			},
+			map[string]string{"python": "3.11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractVersionRequirements(mapFS(tt.files), ".")
+			if err != nil {
+				t.Fatalf("ExtractVersionRequirements() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractVersionRequirements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockFilePythonEvidence(t *testing.T) {
+	files := map[string]string{
+		"Pipfile":      "[packages]\nflask = \"*\"\n\n[requires]\npython_version = \"3.12\"\n",
+		"Pipfile.lock": "{\n  \"_meta\": {\n    \"requires\": {\n      \"python_version\": \"3.12\"\n    }\n  }\n}\n",
+		"app.py":       strings.Repeat("print('hello')\n", 20),
+	}
+	result, err := AnalyzeFS(context.Background(), mapFS(files), "api", &types.AnalysisOptions{Evidence: true})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	component := result.Components[0]
+
+	want := types.Evidence{Kind: "version", Name: "python", File: "Pipfile.lock", Line: 4, Match: "_meta.requires.python_version"}
+	found := false
+	for _, e := range component.Evidence {
+		found = found || e == want
+	}
+	if !found {
+		t.Errorf("Expected evidence %+v, got %+v", want, component.Evidence)
+	}
+	if len(component.VersionConflicts) != 0 {
+		t.Errorf("Expected no version conflicts, got %+v", component.VersionConflicts)
+	}
+}
+
+func TestPythonFrameworks(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		framework string
+		evidence  types.Evidence
+	}{
+		{
+			name: "requirements include",
+			files: map[string]string{
+				"requirements.txt":      "-r requirements/base.txt\ngunicorn\n",
+				"requirements/base.txt": "# shared\nDjango>=4.2\n",
+			},
+			framework: "Django",
+			evidence:  types.Evidence{Kind: "framework", Name: "Django", File: "requirements/base.txt", Line: 2, Match: "django"},
+		},
+		{
+			name:      "pyproject only",
+			files:     map[string]string{"pyproject.toml": "[project]\nname = \"api\"\ndependencies = [\"flask>=3\"]\n"},
+			framework: "Flask",
+			evidence:  types.Evidence{Kind: "framework", Name: "Flask", File: "pyproject.toml", Line: 3, Match: "flask"},
+		},
+		{
+			name:      "mixed case",
+			files:     map[string]string{"requirements.txt": "FastAPI>=0.100\nPyTest\n"},
+			framework: "FastAPI",
+			evidence:  types.Evidence{Kind: "framework", Name: "FastAPI", File: "requirements.txt", Line: 1, Match: "fastapi"},
+		},
+		{
+			name:      "Pipfile mixed case",
+			files:     map[string]string{"Pipfile": "[packages]\nFlask = \"*\"\n"},
+			framework: "Flask",
+			evidence:  types.Evidence{Kind: "framework", Name: "Flask", File: "Pipfile", Line: 2, Match: "flask"},
+		},
+		{
+			name:      "Pipfile",
+			files:     map[string]string{"Pipfile": "[packages]\nfastapi = \"*\"\n"},
+			framework: "FastAPI",
+			evidence:  types.Evidence{Kind: "framework", Name: "FastAPI", File: "Pipfile", Line: 2, Match: "fastapi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files["app.py"] = strings.Repeat("print('hello')\n", 20)
+			result, err := AnalyzeFS(context.Background(), mapFS(tt.files), "api", &types.AnalysisOptions{Evidence: true})
+			if err != nil {
+				t.Fatalf("AnalyzeFS() error = %v", err)
+			}
+			if len(result.Components) != 1 {
+				t.Fatalf("Expected 1 component, got %d", len(result.Components))
+			}
+			component := result.Components[0]
+
+			if component.Framework != tt.framework {
+				t.Errorf("Framework = %q, want %q", component.Framework, tt.framework)
+			}
+			found := false
+			for _, e := range component.Evidence {
+				found = found || e == tt.evidence
+			}
+			if !found {
+				t.Errorf("Expected evidence %+v, got %+v", tt.evidence, component.Evidence)
+			}
+		})
+	}
+}
+
+func TestLockFilesDoNotDetectFrameworks(t *testing.T) {
+	files := map[string]string{
+		"pyproject.toml": "[project]\nname = \"api\"\nrequires-python = \">=3.11\"\ndependencies = [\"connexion\"]\n",
+		"uv.lock":        "version = 1\nrequires-python = \">=3.11\"\n\n[[package]]\nname = \"connexion\"\n\n[[package]]\nname = \"flask\"\n\n[[package]]\nname = \"pytest\"\n",
+		"poetry.lock":    "[[package]]\nname = \"django\"\n",
+		"Pipfile.lock":   `{"default": {"fastapi": {}}, "develop": {"black": {}}}`,
+		"app.py":         strings.Repeat("print('hello')\n", 20),
+	}
+	result, err := AnalyzeFS(context.Background(), mapFS(files), "api", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	component := result.Components[0]
+
+	if component.Framework != "" || len(component.FrameworkCandidates) != 0 {
+		t.Errorf("Expected no framework from transitive packages, got %q %+v", component.Framework, component.FrameworkCandidates)
+	}
+	if len(component.DevelopmentTools) != 0 {
+		t.Errorf("Expected no tools from lock files, got %v", component.DevelopmentTools)
+	}
+	if component.VersionRequirements["python"] != ">=3.11" {
+		t.Errorf("Expected the Python version, got %v", component.VersionRequirements)
+	}
+}
+
+func TestRequirementsIncludeOutsideComponent(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"api/requirements.txt": "-r ../../shared.txt\nflask>=3\n",
+		"api/app.py":           strings.Repeat("print('hello')\n", 20),
+	})
+
+	result, err := AnalyzeRepository(context.Background(), filepath.Join(root, "api"), &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+	if len(result.Components) != 1 {
+		t.Fatalf("Expected 1 component, got %d", len(result.Components))
+	}
+	if framework := result.Components[0].Framework; framework != "Flask" {
+		t.Errorf("Framework = %q, want %q", framework, "Flask")
+	}
+}
@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/archive"
+	"github.com/replyzer/analyze-repo/internal/attributes"
+	"github.com/replyzer/analyze-repo/internal/cache"
+	"github.com/replyzer/analyze-repo/internal/git"
+	"github.com/replyzer/analyze-repo/internal/ignore"
+	"github.com/replyzer/analyze-repo/internal/rules"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// defaultIgnorePatterns are applied with the lowest precedence, so a
+// .gitignore or .replyzerignore negation can still re-include them.
+var defaultIgnorePatterns = []string{
+	".*/",
+	"node_modules/",
+	"__pycache__/",
+	"venv/",
+	"target/",
+	"dist/",
+	"build/",
+	"coverage/",
+}
+
+type repository struct {
+	root    string
+	fsys    fs.FS
+	ignore  *ignore.Matcher
+	objects *git.Repository
+	archive *archive.Archive
+	commit  string
+	ctx     context.Context
+	log     *log.Logger
+
+	detectors []Detector
+	rules     *rules.RuleSet
+	overrides []types.ComponentOverride
+	index     *fileIndex
+	cache     *cache.Cache
+
+	maxFileSize int64 // 0 for no limit
+	// statsMode is the metric language stats are based on.
+	statsMode  string
+	skipped    skippedFiles
+	attributes *attributes.Matcher
+}
+
+func newRepository(root string) *repository {
+	return newRepositoryFS(root, os.DirFS(root))
+}
+
+func newRepositoryFS(root string, fsys fs.FS) *repository {
+	r := &repository{root: root, ctx: context.Background(), rules: rules.Default(), maxFileSize: DefaultMaxFileSize}
+	r.setFS(fsys)
+	return r
+}
+
+func (r *repository) setFS(fsys fs.FS) {
+	r.fsys = fsys
+	r.ignore = ignore.NewMatcher(fsys, defaultIgnorePatterns)
+	r.attributes = attributes.NewMatcher(fsys)
+	r.index = nil
+}
+
+func (r *repository) restrictToTracked() error {
+	files, err := git.TrackedFiles(r.root)
+	if err != nil {
+		return fmt.Errorf("failed to read git index: %w", err)
+	}
+
+	r.setFS(newTrackedFS(r.fsys, files))
+	return nil
+}
+
+func (r *repository) isArchive() bool {
+	if !archive.IsArchive(r.root) {
+		return false
+	}
+	info, err := os.Stat(r.root)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (r *repository) useArchive() error {
+	fsys, err := archive.Open(r.root)
+	if err != nil {
+		return err
+	}
+
+	r.archive = fsys
+	r.setFS(fsys)
+	return nil
+}
+
+func (r *repository) useRevision(rev string) error {
+	objects, err := git.OpenRepository(r.root)
+	if err != nil {
+		return fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	tree, commit, err := objects.TreeFS(rev)
+	if err != nil {
+		objects.Close()
+		return fmt.Errorf("failed to resolve revision %s: %w", rev, err)
+	}
+
+	var fsys fs.FS = tree
+	root, err := filepath.Abs(r.root)
+	if err == nil {
+		root, err = filepath.Rel(objects.WorkTree(), root)
+	}
+	if err != nil {
+		objects.Close()
+		return fmt.Errorf("failed to locate %s in the git repository: %w", r.root, err)
+	}
+	if root != "." {
+		if _, err := fs.Stat(tree, filepath.ToSlash(root)); err != nil {
+			objects.Close()
+			return fmt.Errorf("%s does not exist at revision %s", root, rev)
+		}
+		if fsys, err = fs.Sub(tree, filepath.ToSlash(root)); err != nil {
+			objects.Close()
+			return err
+		}
+	}
+
+	r.objects = objects
+	r.commit = commit
+	r.setFS(fsys)
+	r.addParentIgnores(tree)
+	return nil
+}
+
+// addParentIgnores applies info/exclude and the ignore files above a
+// subdirectory of a git repository. tree is nil for the working tree.
+func (r *repository) addParentIgnores(tree fs.FS) {
+	gitDir, workTree, err := git.FindRepository(r.root)
+	if err != nil {
+		return
+	}
+	root, err := filepath.Abs(r.root)
+	if err != nil {
+		return
+	}
+	prefix, err := filepath.Rel(workTree, root)
+	if err != nil || prefix == "." {
+		return
+	}
+	prefix = filepath.ToSlash(prefix)
+
+	var patterns []ignore.Pattern
+	if content, err := os.ReadFile(git.ExcludeFile(gitDir)); err == nil {
+		patterns = ignore.ParsePatterns(content, "")
+	}
+	if tree == nil {
+		tree = os.DirFS(workTree)
+	}
+	r.ignore.AddParents(prefix, append(patterns, ignore.ReadParents(tree, prefix)...))
+}
+
+func (r *repository) close() {
+	if r.objects != nil {
+		r.objects.Close()
+	}
+	if r.archive != nil {
+		r.archive.Close()
+	}
+}
+
+func (r *repository) walk(dir string, fn func(relPath string, d fs.DirEntry) error) error {
+	return fs.WalkDir(r.fsys, dir, func(relPath string, d fs.DirEntry, err error) error {
+		if ctxErr := r.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+
+		if relPath != dir && r.ignore.Ignored(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		return fn(relPath, d)
+	})
+}
+
+type trackedFS struct {
+	fsys       fs.FS
+	files      map[string]bool
+	dirs       map[string]bool
+	sparseDirs []string
+}
+
+func newTrackedFS(fsys fs.FS, files []string) *trackedFS {
+	t := &trackedFS{
+		fsys:  fsys,
+		files: make(map[string]bool),
+		dirs:  map[string]bool{".": true},
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "/") {
+			t.sparseDirs = append(t.sparseDirs, file)
+			file = strings.TrimSuffix(file, "/")
+		} else {
+			t.files[file] = true
+		}
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			t.dirs[dir] = true
+		}
+	}
+
+	return t
+}
+
+func (t *trackedFS) tracked(name string, isDir bool) bool {
+	for _, dir := range t.sparseDirs {
+		if strings.HasPrefix(name+"/", dir) {
+			return true
+		}
+	}
+	if isDir {
+		return t.dirs[name]
+	}
+	return t.files[name]
+}
+
+func (t *trackedFS) Open(name string) (fs.File, error) {
+	file, err := t.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !t.tracked(name, info.IsDir()) {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file, nil
+}
+
+func (t *trackedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !t.tracked(name, true) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries, err := fs.ReadDir(t.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if t.tracked(path.Join(name, entry.Name()), entry.IsDir()) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
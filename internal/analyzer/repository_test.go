@@ -0,0 +1,233 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestAnalyzeTrackedOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+
+	writeFiles(t, root, map[string]string{
+		".env":               "DATABASE_URL=postgres://localhost/app\n",
+		"scratch.py":         "print('local experiment')\n",
+		"tools/package.json": `{"name": "tools"}`,
+	})
+
+	result, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{TrackedOnly: true})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+
+	if len(result.Components) != 1 {
+		t.Fatalf("Expected only the tracked component, got %d", len(result.Components))
+	}
+
+	comp := result.Components[0]
+	if _, exists := comp.LanguageStats["Python"]; exists {
+		t.Errorf("Untracked Python file was counted: %v", comp.LanguageStats)
+	}
+	if len(comp.ExternalDependencies.Databases) != 0 {
+		t.Errorf("Untracked .env file was analyzed: %v", comp.ExternalDependencies.Databases)
+	}
+
+	result, err = AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+	if len(result.Components) != 2 {
+		t.Errorf("Expected untracked component without --tracked-only, got %d components", len(result.Components))
+	}
+}
+
+func TestAnalyzeSubdirectoryIgnores(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude":                "scratch.py\n",
+		".gitignore":                       "generated/\n",
+		"services/.gitignore":              "*.rb\n",
+		"services/api/go.mod":              "module example.com/api\n\ngo 1.22\n",
+		"services/api/main.go":             strings.Repeat("package main\n", 10),
+		"services/api/scratch.py":          "print('local experiment')\n",
+		"services/api/tool.rb":             "puts 'tool'\n",
+		"services/api/generated/client.js": "module.exports = {};\n",
+		"services/api/dist/bundle.ts":      "export const bundle = 1;\n",
+		"services/api/coverage/lcov.ts":    "export const lcov = 1;\n",
+	})
+
+	result, err := AnalyzeRepository(context.Background(), filepath.Join(root, "services", "api"), &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+	if len(result.Components) != 1 {
+		t.Fatalf("Expected one component, got %d", len(result.Components))
+	}
+	if stats := result.Components[0].LanguageStats; len(stats) != 2 || stats["Go"] == 0 || stats["Go Module"] == 0 {
+		t.Errorf("Expected only Go files to be counted, got %v", stats)
+	}
+}
+
+func TestAnalyzeRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"web/package.json": `{"name": "web", "engines": {"node": ">=16"}, "dependencies": {"react": "^17.0.0"}}`,
+		"web/index.js":     strings.Repeat("console.log('web');\n", 20),
+	})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "v1")
+	runGit(t, root, "tag", "v1")
+	runGit(t, root, "gc", "-q")
+
+	writeFiles(t, root, map[string]string{
+		"web/package.json": `{"name": "web", "engines": {"node": ">=20"}, "dependencies": {"vue": "^3.0.0"}}`,
+		"api/go.mod":       "module example.com/api\n\ngo 1.22\n",
+		"api/main.go":      "package main\n\nfunc main() {}\n",
+	})
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "v2")
+
+	result, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{Revision: "v1"})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+
+	if result.Repository.Revision != "v1" || len(result.Repository.Commit) != 40 {
+		t.Errorf("Expected revision v1 with its commit id, got %q %q", result.Repository.Revision, result.Repository.Commit)
+	}
+	if len(result.Components) != 1 {
+		t.Fatalf("Expected only the component present at v1, got %d", len(result.Components))
+	}
+
+	comp := result.Components[0]
+	if comp.Framework != "React" {
+		t.Errorf("Expected framework React at v1, got %s", comp.Framework)
+	}
+	if comp.VersionRequirements["node"] != ">=16" {
+		t.Errorf("Expected node >=16 at v1, got %s", comp.VersionRequirements["node"])
+	}
+	if comp.PrimaryLanguage != "JavaScript" {
+		t.Errorf("Expected JavaScript at v1, got %s", comp.PrimaryLanguage)
+	}
+
+	sub, err := AnalyzeRepository(context.Background(), filepath.Join(root, "web"), &types.AnalysisOptions{Revision: "HEAD"})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() on a subdirectory error = %v", err)
+	}
+	if len(sub.Components) != 1 || sub.Components[0].Framework != "Vue" {
+		t.Errorf("Expected the web component at HEAD, got %+v", sub.Components)
+	}
+
+	if _, err := AnalyzeRepository(context.Background(), root, &types.AnalysisOptions{Revision: "missing"}); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
+func TestAnalyzeArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"shop-2.1.0/web/package.json":       `{"name": "web", "engines": {"node": ">=18"}, "dependencies": {"express": "^4.0.0"}}`,
+		"shop-2.1.0/web/server.js":          strings.Repeat("app.get('/', handler);\n", 20),
+		"shop-2.1.0/web/docker-compose.yml": "services:\n  db:\n    image: postgres:15\n",
+		"shop-2.1.0/worker/go.mod":          "module example.com/worker\n\ngo 1.22\n",
+		"shop-2.1.0/worker/main.go":         "package main\n\nfunc main() {\n\tprintln(\"processing jobs\")\n}\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "shop-2.1.0.zip")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := AnalyzeRepository(context.Background(), archivePath, &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeRepository() error = %v", err)
+	}
+
+	if result.Repository.Name != "shop-2.1.0.zip" {
+		t.Errorf("Expected archive name as repository name, got %s", result.Repository.Name)
+	}
+	if result.Repository.Type != "monorepo" || len(result.Components) != 2 {
+		t.Fatalf("Expected a monorepo with 2 components, got %s with %d", result.Repository.Type, len(result.Components))
+	}
+
+	for _, comp := range result.Components {
+		switch comp.Name {
+		case "web":
+			if comp.Framework != "Express" || comp.VersionRequirements["node"] != ">=18" {
+				t.Errorf("Unexpected web component: %+v", comp)
+			}
+			if len(comp.ExternalDependencies.Databases) != 1 {
+				t.Errorf("Expected postgres from docker-compose.yml, got %v", comp.ExternalDependencies.Databases)
+			}
+		case "worker":
+			if comp.PrimaryLanguage != "Go" || comp.VersionRequirements["go"] != "1.22" {
+				t.Errorf("Unexpected worker component: %+v", comp)
+			}
+		default:
+			t.Errorf("Unexpected component %s", comp.Name)
+		}
+	}
+
+	if _, err := AnalyzeRepository(context.Background(), archivePath, &types.AnalysisOptions{Revision: "HEAD"}); err == nil {
+		t.Error("Expected an error when combining an archive with a revision")
+	}
+}
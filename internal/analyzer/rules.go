@@ -0,0 +1,308 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/rules"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// A framework that is declared, configured and imported has a confidence of 1.
+const (
+	manifestConfidence = 0.6
+	fileConfidence     = 0.25
+	importConfidence   = 0.15
+)
+
+type ruleMatch struct {
+	manifest []types.Evidence // declared dependencies and manifest strings
+	files    []types.Evidence
+	imports  []types.Evidence
+}
+
+func (m *ruleMatch) evidence() []types.Evidence {
+	evidence := append([]types.Evidence(nil), m.manifest...)
+	evidence = append(evidence, m.files...)
+	return append(evidence, m.imports...)
+}
+
+func (m *ruleMatch) confidence() float64 {
+	confidence := 0.0
+	if len(m.manifest) > 0 {
+		confidence += manifestConfidence
+	}
+	if len(m.files) > 0 {
+		confidence += fileConfidence
+	}
+	if len(m.imports) > 0 {
+		confidence += importConfidence
+	}
+	return math.Round(confidence*100) / 100
+}
+
+// matchRule collects the evidence of rule in the component directory. Each
+// imported module is reported once, at its first import.
+func (r *repository) matchRule(dir string, rule *rules.Rule, kind string, imports []sourceImport) (*ruleMatch, error) {
+	match := &ruleMatch{}
+	for _, file := range rule.Files {
+		if r.fileExists(path.Join(dir, file)) {
+			match.files = append(match.files, types.Evidence{Kind: kind, Name: rule.Name, File: path.Join(dir, file), Match: file})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, imported := range imports {
+		for _, module := range rule.Imports {
+			if importsModule(imported.module, module) && !seen[imported.module] {
+				seen[imported.module] = true
+				match.imports = append(match.imports, types.Evidence{
+					Kind:  kind,
+					Name:  rule.Name,
+					File:  imported.file,
+					Line:  imported.line,
+					Match: imported.module,
+				})
+			}
+		}
+	}
+
+	if len(rule.Manifest) == 0 {
+		return match, nil
+	}
+
+	manifests, included, err := r.manifests(dir, rule.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, manifest := range manifests {
+		if len(rule.Dependencies) > 0 {
+			format := path.Base(manifest)
+			if included[manifest] {
+				format = "requirements.txt"
+			}
+			declared, err := r.dependencyLines(manifest, format)
+			if errors.Is(err, fs.ErrNotExist) || err != nil && included[manifest] {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, dependency := range rule.Dependencies {
+				if line, exists := declared[dependencyKey(format, dependency)]; exists {
+					match.manifest = append(match.manifest, types.Evidence{
+						Kind:  kind,
+						Name:  rule.Name,
+						File:  manifest,
+						Line:  line,
+						Match: dependency,
+					})
+				}
+			}
+		}
+
+		if len(rule.Contains) == 0 {
+			continue
+		}
+
+		content, err := fs.ReadFile(r.fsys, manifest)
+		if errors.Is(err, fs.ErrNotExist) || err != nil && included[manifest] {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pattern := range rule.Contains {
+			if line := lineOf(content, pattern); line > 0 {
+				match.manifest = append(match.manifest, types.Evidence{Kind: kind, Name: rule.Name, File: manifest, Line: line, Match: pattern})
+			}
+		}
+	}
+
+	return match, nil
+}
+
+// manifests returns the files in dir matching patterns, followed by the
+// requirements files they include. Included files that cannot be read are
+// treated as missing, like a -r that pip could not follow.
+func (r *repository) manifests(dir string, patterns []string) (manifests []string, included map[string]bool, err error) {
+	seen := make(map[string]bool)
+	included = make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := r.glob(path.Join(dir, pattern))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, manifest := range matches {
+			if !seen[manifest] {
+				seen[manifest] = true
+				manifests = append(manifests, manifest)
+			}
+		}
+	}
+
+	for i := 0; i < len(manifests); i++ {
+		if !isRequirementsFile(path.Base(manifests[i])) && !included[manifests[i]] {
+			continue
+		}
+		includes, err := r.requirementIncludes(manifests[i])
+		if errors.Is(err, fs.ErrNotExist) || err != nil && included[manifests[i]] {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, include := range includes {
+			if !seen[include] {
+				seen[include] = true
+				included[include] = true
+				manifests = append(manifests, include)
+			}
+		}
+	}
+
+	return manifests, included, nil
+}
+
+// dependencyLines returns the line each package declared in manifest is
+// first mentioned on. format is the file name the manifest is parsed as.
+func (r *repository) dependencyLines(manifest, format string) (map[string]int, error) {
+	var lines map[string]int
+	err := r.readCached(manifest, cacheDependencies, &lines, func(content []byte) {
+		lines = make(map[string]int)
+		for dependency := range manifestDependencies(format, content) {
+			line := lineOf(content, `"`+dependency+`"`, dependency)
+			key := dependencyKey(format, dependency)
+			if previous, exists := lines[key]; !exists || line < previous {
+				lines[key] = line
+			}
+		}
+	})
+	return lines, err
+}
+
+// dependencyKey normalizes Python package names; other ecosystems compare
+// names exactly.
+func dependencyKey(format, name string) string {
+	if isPythonManifest(format) {
+		return normalizePackageName(name)
+	}
+	return name
+}
+
+// Manifests that cannot be parsed declare nothing.
+func manifestDependencies(name string, content []byte) map[string]bool {
+	declared := make(map[string]bool)
+
+	switch name {
+	case "package.json":
+		var packageJson struct {
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal(content, &packageJson) == nil {
+			for pkg := range packageJson.Dependencies {
+				declared[pkg] = true
+			}
+			for pkg := range packageJson.DevDependencies {
+				declared[pkg] = true
+			}
+		}
+	case "composer.json":
+		var composer struct {
+			Require    map[string]string `json:"require"`
+			RequireDev map[string]string `json:"require-dev"`
+		}
+		if json.Unmarshal(content, &composer) == nil {
+			for pkg := range composer.Require {
+				declared[pkg] = true
+			}
+			for pkg := range composer.RequireDev {
+				declared[pkg] = true
+			}
+		}
+	case "pyproject.toml":
+		if project, err := parsePyproject(content); err == nil {
+			declared = project.dependencies()
+		}
+	case "Cargo.toml":
+		if manifest, err := parseCargoManifest(content); err == nil {
+			for _, dependency := range manifest.dependencies() {
+				declared[dependency.Name] = true
+			}
+		}
+	default:
+		if names, ok := pythonManifestDependencies(name, content); ok {
+			for _, pkg := range names {
+				declared[pkg] = true
+			}
+		}
+	}
+
+	return declared
+}
+
+// categorizeImage reports unknown images from a registry namespace as
+// services under their image name.
+func (r *repository) categorizeImage(image string, component *types.Component, evidence types.Evidence) {
+	imageLower := strings.ToLower(image)
+	name := imageName(imageLower)
+
+	for _, rule := range r.rules.Services {
+		for _, pattern := range rule.Images {
+			if strings.Contains(name, strings.ToLower(pattern)) {
+				addService(component, rule, evidence)
+				return
+			}
+		}
+	}
+
+	if !strings.Contains(imageLower, "/") || strings.Contains(imageLower, "scratch") {
+		return
+	}
+
+	addService(component, rules.Rule{Name: image, Kind: rules.KindService}, evidence)
+}
+
+// imageName strips the registry, namespace, tag and digest from an image, so
+// that apache/kafka:3.7 is named kafka.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = image[strings.LastIndex(image, "/")+1:]
+	image, _, _ = strings.Cut(image, ":")
+	return image
+}
+
+func (r *repository) categorizeEnvLine(line string, component *types.Component, evidence types.Evidence) {
+	lineLower := strings.ToLower(line)
+	key, _, _ := strings.Cut(lineLower, "=")
+
+	for _, rule := range r.rules.Services {
+		if !containsAny(key, rule.EnvKeys) {
+			continue
+		}
+		for _, pattern := range rule.Env {
+			if strings.Contains(lineLower, strings.ToLower(pattern)) {
+				addService(component, rule, evidence)
+				break
+			}
+		}
+	}
+}
+
+// containsAny also reports true if there are no patterns.
+func containsAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(s, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
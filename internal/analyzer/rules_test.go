@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestAnalyzeWithRules(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(rulesFile, []byte(`frameworks:
+  - name: Svelte
+    languages: [JavaScript]
+    manifest: package.json
+    dependencies: [svelte, "@sveltejs/kit"]
+    files: [svelte.config.js]
+services:
+  - name: ClickHouse
+    kind: database
+    images: [clickhouse]
+    env: [clickhouse]
+tools:
+  - name: Vitest
+    manifest: package.json
+    dependencies: [vitest]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"package.json": &fstest.MapFile{Data: []byte(`{
+			"name": "dashboard",
+			"dependencies": {"react": "^18.0.0", "svelte": "^4.0.0", "@sveltejs/kit": "^2.0.0"},
+			"devDependencies": {"vitest": "^1.0.0"}
+		}`)},
+		"svelte.config.js":   &fstest.MapFile{Data: []byte("export default {};\n")},
+		"src/app.js":         &fstest.MapFile{Data: []byte(strings.Repeat("export const app = {};\n", 20))},
+		"docker-compose.yml": &fstest.MapFile{Data: []byte("services:\n  olap:\n    image: clickhouse/clickhouse-server:24\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "dashboard", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	comp := result.Components[0]
+	if comp.Framework != "React" {
+		t.Errorf("Expected React without extra rules, got %s", comp.Framework)
+	}
+	if !reflect.DeepEqual(comp.ExternalDependencies.Services, []string{"clickhouse/clickhouse-server:24"}) {
+		t.Errorf("Expected the unknown image as a service, got %+v", comp.ExternalDependencies)
+	}
+
+	result, err = AnalyzeFS(context.Background(), fsys, "dashboard", &types.AnalysisOptions{RuleFiles: []string{rulesFile}})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	comp = result.Components[0]
+	if comp.Framework != "Svelte" {
+		t.Errorf("Expected the most confident rule Svelte, got %s", comp.Framework)
+	}
+	if !reflect.DeepEqual(comp.ExternalDependencies.Databases, []string{"ClickHouse"}) {
+		t.Errorf("Expected ClickHouse, got %+v", comp.ExternalDependencies)
+	}
+	if !reflect.DeepEqual(comp.DevelopmentTools, []string{"Vitest"}) {
+		t.Errorf("Expected Vitest, got %v", comp.DevelopmentTools)
+	}
+
+	_, err = AnalyzeFS(context.Background(), fsys, "dashboard", &types.AnalysisOptions{RuleFiles: []string{rulesFile + ".missing"}})
+	if err == nil {
+		t.Error("Expected an error for a missing rules file")
+	}
+}
+
+func TestCategorizeImage(t *testing.T) {
+	tests := []struct {
+		image     string
+		databases []string
+		services  []string
+	}{
+		{"apache/kafka:3.7.0", []string{}, []string{"Apache Kafka"}},
+		{"apache/zookeeper", []string{}, []string{"Apache Zookeeper"}},
+		{"bitnami/apache:2.4", []string{}, []string{"Apache"}},
+		{"localhost:5000/redis:7", []string{"Redis"}, []string{}},
+		{"docker.elastic.co/elasticsearch/elasticsearch:8.13.0", []string{"Elasticsearch"}, []string{}},
+		{"postgres@sha256:0123abcd", []string{"PostgreSQL"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"compose.yaml": &fstest.MapFile{Data: []byte("services:\n  svc:\n    image: " + tt.image + "\n")},
+			}
+			deps, err := DetectExternalDependencies(fsys, ".")
+			if err != nil {
+				t.Fatalf("DetectExternalDependencies() error = %v", err)
+			}
+			if !reflect.DeepEqual(deps.Databases, tt.databases) || !reflect.DeepEqual(deps.Services, tt.services) {
+				t.Errorf("DetectExternalDependencies() = %+v, want databases %v and services %v", deps, tt.databases, tt.services)
+			}
+		})
+	}
+}
+
+func TestCategorizeEnvLine(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env": &fstest.MapFile{Data: []byte("REDIS_URL=redis://cache\nMONGO_URL=mongodb://db\nDATABASE_URL=postgres://db\n" +
+			"CACHE_HOST=memcached\nLOG_FORMAT=mysql\ndb_backup=sqlite:///backup.db\n")},
+	}
+
+	deps, err := DetectExternalDependencies(fsys, ".")
+	if err != nil {
+		t.Fatalf("DetectExternalDependencies() error = %v", err)
+	}
+	if !reflect.DeepEqual(deps.Databases, []string{"PostgreSQL", "SQLite"}) ||
+		!reflect.DeepEqual(deps.Services, []string{"Redis", "Memcached"}) {
+		t.Errorf("Expected only database and cache variables to match, got %+v", deps)
+	}
+}
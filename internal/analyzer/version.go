@@ -3,71 +3,102 @@ package analyzer
 import (
 	"encoding/json"
 	"encoding/xml"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"regexp"
 	"strings"
-)
-
-func ExtractVersionRequirements(componentPath string) (map[string]string, error) {
-	requirements := make(map[string]string)
-
-	if err := extractNodeVersions(componentPath, requirements); err != nil {
-		return requirements, err
-	}
-
-	if err := extractPythonVersions(componentPath, requirements); err != nil {
-		return requirements, err
-	}
 
-	if err := extractJavaVersions(componentPath, requirements); err != nil {
-		return requirements, err
-	}
+	"github.com/BurntSushi/toml"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
 
-	if err := extractGoVersions(componentPath, requirements); err != nil {
-		return requirements, err
-	}
+func ExtractVersionRequirements(fsys fs.FS, dir string) (map[string]string, error) {
+	return newRepositoryFS("", fsys).versionRequirements(dir)
+}
 
-	if err := extractRustVersions(componentPath, requirements); err != nil {
-		return requirements, err
+func (r *repository) versionRequirements(dir string) (map[string]string, error) {
+	component := &types.Component{VersionRequirements: make(map[string]string)}
+
+	extractors := []func(dir string, component *types.Component) error{
+		r.extractNodeVersions,
+		r.extractPythonVersions,
+		r.extractJavaVersions,
+		r.extractGoVersions,
+		r.extractRustVersions,
+		r.extractDotNetVersions,
+		r.extractVersionManagerVersions,
 	}
-
-	if err := extractDotNetVersions(componentPath, requirements); err != nil {
-		return requirements, err
+	for _, extract := range extractors {
+		if err := extract(dir, component); err != nil {
+			return component.VersionRequirements, err
+		}
 	}
 
-	return requirements, nil
+	return component.VersionRequirements, nil
 }
 
-func extractNodeVersions(componentPath string, requirements map[string]string) error {
-	packageJsonPath := filepath.Join(componentPath, "package.json")
-	if _, err := os.Stat(packageJsonPath); err == nil {
-		data, err := os.ReadFile(packageJsonPath)
+func (r *repository) extractNodeVersions(dir string, component *types.Component) error {
+	packageJsonPath := path.Join(dir, "package.json")
+	if r.fileExists(packageJsonPath) {
+		data, err := fs.ReadFile(r.fsys, packageJsonPath)
 		if err != nil {
 			return err
 		}
 
 		var packageJson struct {
-			Engines map[string]string `json:"engines"`
+			Engines        map[string]string `json:"engines"`
+			PackageManager string            `json:"packageManager"`
+			Volta          map[string]any    `json:"volta"`
 		}
 
 		if err := json.Unmarshal(data, &packageJson); err == nil {
 			if nodeVersion, exists := packageJson.Engines["node"]; exists {
-				requirements["node"] = nodeVersion
+				setVersion(component, "node", nodeVersion, types.Evidence{
+					File:  packageJsonPath,
+					Line:  lineOf(data, `"node"`),
+					Match: "engines.node",
+				})
 			}
 			if npmVersion, exists := packageJson.Engines["npm"]; exists {
-				requirements["npm"] = npmVersion
+				setVersion(component, "npm", npmVersion, types.Evidence{
+					File:  packageJsonPath,
+					Line:  lineOf(data, `"npm"`),
+					Match: "engines.npm",
+				})
+			}
+
+			// Volta pins exact versions of node and the package managers.
+			for _, tool := range []string{"node", "npm", "pnpm", "yarn"} {
+				if version, ok := packageJson.Volta[tool].(string); ok && version != "" {
+					setVersion(component, tool, version, types.Evidence{
+						File:  packageJsonPath,
+						Line:  lineOf(data, `"volta"`),
+						Match: "volta." + tool,
+					})
+				}
+			}
+
+			// Corepack enforces packageManager, so it wins over Volta.
+			if manager, version, found := strings.Cut(packageJson.PackageManager, "@"); found && manager != "" {
+				version, _, _ = strings.Cut(version, "+")
+				if version != "" {
+					setVersion(component, manager, version, types.Evidence{
+						File:  packageJsonPath,
+						Line:  lineOf(data, `"packageManager"`),
+						Match: "packageManager",
+					})
+				}
 			}
 		}
 	}
 
-	nvmrcPath := filepath.Join(componentPath, ".nvmrc")
-	if _, err := os.Stat(nvmrcPath); err == nil {
-		content, err := os.ReadFile(nvmrcPath)
+	nvmrcPath := path.Join(dir, ".nvmrc")
+	if r.fileExists(nvmrcPath) {
+		content, err := fs.ReadFile(r.fsys, nvmrcPath)
 		if err == nil {
 			version := strings.TrimSpace(string(content))
 			if version != "" {
-				requirements["node"] = version
+				setVersion(component, "node", version, types.Evidence{File: nvmrcPath, Line: 1, Match: version})
 			}
 		}
 	}
@@ -75,28 +106,126 @@ func extractNodeVersions(componentPath string, requirements map[string]string) e
 	return nil
 }
 
-func extractPythonVersions(componentPath string, requirements map[string]string) error {
-	pyprojectPath := filepath.Join(componentPath, "pyproject.toml")
-	if _, err := os.Stat(pyprojectPath); err == nil {
-		content, err := os.ReadFile(pyprojectPath)
+// Later sources win, so conda environments and .python-version, which pin
+// the interpreter actually used, are read last.
+func (r *repository) extractPythonVersions(dir string, component *types.Component) error {
+	for _, name := range []string{"setup.py", "setup.cfg"} {
+		setupPath := path.Join(dir, name)
+		if !r.fileExists(setupPath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, setupPath)
+		if err != nil {
+			return err
+		}
+
+		config := parseSetupScript(content)
+		if name == "setup.cfg" {
+			config = parseSetupConfig(content)
+		}
+		if config.PythonRequires != "" {
+			setVersion(component, "python", config.PythonRequires, types.Evidence{
+				File:  setupPath,
+				Line:  lineOf(content, "python_requires"),
+				Match: "python_requires",
+			})
+		}
+	}
+
+	pyprojectPath := path.Join(dir, "pyproject.toml")
+	if r.fileExists(pyprojectPath) {
+		content, err := fs.ReadFile(r.fsys, pyprojectPath)
+		if err != nil {
+			return err
+		}
+
+		if project, err := parsePyproject(content); err == nil {
+			if version, key := project.pythonRequirement(); version != "" {
+				setVersion(component, "python", version, types.Evidence{
+					File:  pyprojectPath,
+					Line:  tomlKeyLine(content, key),
+					Match: key,
+				})
+			}
+		}
+	}
+
+	pipfilePath := path.Join(dir, "Pipfile")
+	if r.fileExists(pipfilePath) {
+		content, err := fs.ReadFile(r.fsys, pipfilePath)
+		if err != nil {
+			return err
+		}
+
+		if file, err := parsePipfile(content); err == nil {
+			if version, key := file.pythonRequirement(); version != "" {
+				setVersion(component, "python", version, types.Evidence{
+					File:  pipfilePath,
+					Line:  tomlKeyLine(content, key),
+					Match: key,
+				})
+			}
+		}
+	}
+
+	uvLockPath := path.Join(dir, "uv.lock")
+	if r.fileExists(uvLockPath) {
+		content, err := fs.ReadFile(r.fsys, uvLockPath)
 		if err != nil {
 			return err
 		}
 
-		re := regexp.MustCompile(`requires-python\s*=\s*"([^"]+)"`)
-		matches := re.FindStringSubmatch(string(content))
-		if len(matches) > 1 {
-			requirements["python"] = matches[1]
+		if version := uvLockPython(content); version != "" {
+			setVersion(component, "python", version, types.Evidence{
+				File:  uvLockPath,
+				Line:  tomlKeyLine(content, "requires-python"),
+				Match: "requires-python",
+			})
 		}
 	}
 
-	pythonVersionPath := filepath.Join(componentPath, ".python-version")
-	if _, err := os.Stat(pythonVersionPath); err == nil {
-		content, err := os.ReadFile(pythonVersionPath)
+	pipfileLockPath := path.Join(dir, "Pipfile.lock")
+	if r.fileExists(pipfileLockPath) {
+		content, err := fs.ReadFile(r.fsys, pipfileLockPath)
+		if err != nil {
+			return err
+		}
+
+		if version, key := pipfileLockPython(content); version != "" {
+			setVersion(component, "python", version, types.Evidence{
+				File:  pipfileLockPath,
+				Line:  lineOf(content, `"`+key+`"`),
+				Match: "_meta.requires." + key,
+			})
+		}
+	}
+
+	for _, name := range []string{"environment.yml", "environment.yaml"} {
+		environmentPath := path.Join(dir, name)
+		if !r.fileExists(environmentPath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, environmentPath)
+		if err != nil {
+			return err
+		}
+
+		if _, version := parseCondaEnvironment(content); version != "" {
+			setVersion(component, "python", version, types.Evidence{
+				File:  environmentPath,
+				Line:  lineOf(content, "- python", "python="),
+				Match: "dependencies.python",
+			})
+		}
+	}
+
+	pythonVersionPath := path.Join(dir, ".python-version")
+	if r.fileExists(pythonVersionPath) {
+		content, err := fs.ReadFile(r.fsys, pythonVersionPath)
 		if err == nil {
 			version := strings.TrimSpace(string(content))
 			if version != "" {
-				requirements["python"] = version
+				setVersion(component, "python", version, types.Evidence{File: pythonVersionPath, Line: 1, Match: version})
 			}
 		}
 	}
@@ -104,10 +233,10 @@ func extractPythonVersions(componentPath string, requirements map[string]string)
 	return nil
 }
 
-func extractJavaVersions(componentPath string, requirements map[string]string) error {
-	pomPath := filepath.Join(componentPath, "pom.xml")
-	if _, err := os.Stat(pomPath); err == nil {
-		content, err := os.ReadFile(pomPath)
+func (r *repository) extractJavaVersions(dir string, component *types.Component) error {
+	pomPath := path.Join(dir, "pom.xml")
+	if r.fileExists(pomPath) {
+		content, err := fs.ReadFile(r.fsys, pomPath)
 		if err != nil {
 			return err
 		}
@@ -122,23 +251,35 @@ func extractJavaVersions(componentPath string, requirements map[string]string) e
 
 		if err := xml.Unmarshal(content, &pom); err == nil {
 			if pom.Properties.MavenCompilerSource != "" {
-				requirements["java"] = pom.Properties.MavenCompilerSource
+				setVersion(component, "java", pom.Properties.MavenCompilerSource, types.Evidence{
+					File:  pomPath,
+					Line:  lineOf(content, "<maven.compiler.source>"),
+					Match: "properties.maven.compiler.source",
+				})
 			} else if pom.Properties.JavaVersion != "" {
-				requirements["java"] = pom.Properties.JavaVersion
+				setVersion(component, "java", pom.Properties.JavaVersion, types.Evidence{
+					File:  pomPath,
+					Line:  lineOf(content, "<java.version>"),
+					Match: "properties.java.version",
+				})
 			}
 		}
 	}
 
-	gradlePath := filepath.Join(componentPath, "build.gradle")
-	if _, err := os.Stat(gradlePath); err == nil {
-		content, err := os.ReadFile(gradlePath)
+	gradlePath := path.Join(dir, "build.gradle")
+	if r.fileExists(gradlePath) {
+		content, err := fs.ReadFile(r.fsys, gradlePath)
 		if err == nil {
 			contentStr := string(content)
 			
 			re := regexp.MustCompile(`sourceCompatibility\s*=\s*['"]([\d.]+)['"]`)
 			matches := re.FindStringSubmatch(contentStr)
 			if len(matches) > 1 {
-				requirements["java"] = matches[1]
+				setVersion(component, "java", matches[1], types.Evidence{
+					File:  gradlePath,
+					Line:  lineOf(content, matches[0]),
+					Match: matches[0],
+				})
 			}
 		}
 	}
@@ -146,23 +287,29 @@ func extractJavaVersions(componentPath string, requirements map[string]string) e
 	return nil
 }
 
-func extractGoVersions(componentPath string, requirements map[string]string) error {
-	goModPath := filepath.Join(componentPath, "go.mod")
-	if _, err := os.Stat(goModPath); err == nil {
-		content, err := os.ReadFile(goModPath)
+func (r *repository) extractGoVersions(dir string, component *types.Component) error {
+	goModPath := path.Join(dir, "go.mod")
+	if r.fileExists(goModPath) {
+		content, err := fs.ReadFile(r.fsys, goModPath)
 		if err != nil {
 			return err
 		}
 
 		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
+		for i, line := range lines {
 			line = strings.TrimSpace(line)
 			if strings.HasPrefix(line, "go ") {
 				version := strings.TrimSpace(strings.TrimPrefix(line, "go"))
 				if version != "" {
-					requirements["go"] = version
+					setVersion(component, "go", version, types.Evidence{File: goModPath, Line: i + 1, Match: line})
+				}
+			}
+			// "toolchain default" means none.
+			if strings.HasPrefix(line, "toolchain ") {
+				toolchain := strings.TrimSpace(strings.TrimPrefix(line, "toolchain"))
+				if version := strings.TrimPrefix(toolchain, "go"); version != "" && toolchain != "default" {
+					setVersion(component, "go-toolchain", version, types.Evidence{File: goModPath, Line: i + 1, Match: line})
 				}
-				break
 			}
 		}
 	}
@@ -170,33 +317,124 @@ func extractGoVersions(componentPath string, requirements map[string]string) err
 	return nil
 }
 
-func extractRustVersions(componentPath string, requirements map[string]string) error {
-	cargoPath := filepath.Join(componentPath, "Cargo.toml")
-	if _, err := os.Stat(cargoPath); err == nil {
-		content, err := os.ReadFile(cargoPath)
+func (r *repository) extractRustVersions(dir string, component *types.Component) error {
+	cargoPath := path.Join(dir, "Cargo.toml")
+	if r.fileExists(cargoPath) {
+		content, err := fs.ReadFile(r.fsys, cargoPath)
 		if err != nil {
 			return err
 		}
 
-		re := regexp.MustCompile(`rust-version\s*=\s*"([^"]+)"`)
-		matches := re.FindStringSubmatch(string(content))
-		if len(matches) > 1 {
-			requirements["rust"] = matches[1]
+		if manifest, err := parseCargoManifest(content); err == nil {
+			r.extractCargoRustVersion(dir, component, cargoPath, content, manifest)
+		}
+	}
+
+	// rustup prefers the legacy rust-toolchain file when both exist.
+	for _, name := range []string{"rust-toolchain.toml", "rust-toolchain"} {
+		toolchainPath := path.Join(dir, name)
+		if !r.fileExists(toolchainPath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, toolchainPath)
+		if err != nil {
+			continue
 		}
+		extractRustToolchain(component, toolchainPath, content)
 	}
 
 	return nil
 }
 
-func extractDotNetVersions(componentPath string, requirements map[string]string) error {
-	pattern := filepath.Join(componentPath, "*.csproj")
-	matches, err := filepath.Glob(pattern)
+// extractCargoRustVersion reads package.rust-version, following
+// rust-version.workspace = true to the workspace root. A virtual workspace
+// reports the version it lets members inherit.
+func (r *repository) extractCargoRustVersion(dir string, component *types.Component, cargoPath string, content []byte, manifest *cargoManifest) {
+	if manifest.Package == nil {
+		if manifest.Workspace != nil && manifest.Workspace.Package.RustVersion != "" {
+			setVersion(component, "rust", manifest.Workspace.Package.RustVersion, types.Evidence{
+				File:  cargoPath,
+				Line:  tomlKeyLine(content, "workspace.package.rust-version"),
+				Match: "workspace.package.rust-version",
+			})
+		}
+		return
+	}
+
+	switch version := manifest.Package.RustVersion.(type) {
+	case string:
+		setVersion(component, "rust", version, types.Evidence{
+			File:  cargoPath,
+			Line:  tomlKeyLine(content, "package.rust-version"),
+			Match: "package.rust-version",
+		})
+	case map[string]any:
+		if inherited, _ := version["workspace"].(bool); !inherited {
+			return
+		}
+		rootDir, root, rootContent := r.cargoWorkspaceRoot(dir, manifest)
+		if root == nil || root.Workspace.Package.RustVersion == "" {
+			return
+		}
+		setVersion(component, "rust", root.Workspace.Package.RustVersion, types.Evidence{
+			File:  path.Join(rootDir, "Cargo.toml"),
+			Line:  tomlKeyLine(rootContent, "workspace.package.rust-version"),
+			Match: "workspace.package.rust-version",
+		})
+	}
+}
+
+// Legacy rust-toolchain files hold just the channel.
+func extractRustToolchain(component *types.Component, toolchainPath string, content []byte) {
+	var file struct {
+		Toolchain *struct {
+			Channel    string   `toml:"channel"`
+			Components []string `toml:"components"`
+			Targets    []string `toml:"targets"`
+		} `toml:"toolchain"`
+	}
+
+	if toml.Unmarshal(content, &file) != nil || file.Toolchain == nil {
+		channel := strings.TrimSpace(string(content))
+		if channel != "" && !strings.ContainsAny(channel, "\n=[") {
+			setVersion(component, "rust-channel", channel, types.Evidence{File: toolchainPath, Line: 1, Match: channel})
+		}
+		return
+	}
+
+	toolchain := file.Toolchain
+	if toolchain.Channel != "" {
+		setVersion(component, "rust-channel", toolchain.Channel, types.Evidence{
+			File:  toolchainPath,
+			Line:  lineOf(content, "channel"),
+			Match: "toolchain.channel",
+		})
+	}
+	if len(toolchain.Components) > 0 {
+		setVersion(component, "rust-components", strings.Join(toolchain.Components, ","), types.Evidence{
+			File:  toolchainPath,
+			Line:  lineOf(content, "components"),
+			Match: "toolchain.components",
+		})
+	}
+	if len(toolchain.Targets) > 0 {
+		setVersion(component, "rust-targets", strings.Join(toolchain.Targets, ","), types.Evidence{
+			File:  toolchainPath,
+			Line:  lineOf(content, "targets"),
+			Match: "toolchain.targets",
+		})
+	}
+}
+
+func (r *repository) extractDotNetVersions(dir string, component *types.Component) error {
+	pattern := path.Join(dir, "*.csproj")
+	matches, err := r.glob(pattern)
 	if err != nil {
 		return err
 	}
 
 	for _, match := range matches {
-		content, err := os.ReadFile(match)
+		content, err := fs.ReadFile(r.fsys, match)
 		if err != nil {
 			continue
 		}
@@ -209,15 +447,19 @@ func extractDotNetVersions(componentPath string, requirements map[string]string)
 
 		if err := xml.Unmarshal(content, &project); err == nil {
 			if project.PropertyGroup.TargetFramework != "" {
-				requirements["dotnet"] = project.PropertyGroup.TargetFramework
+				setVersion(component, "dotnet", project.PropertyGroup.TargetFramework, types.Evidence{
+					File:  match,
+					Line:  lineOf(content, "<TargetFramework>"),
+					Match: "PropertyGroup.TargetFramework",
+				})
 				break
 			}
 		}
 	}
 
-	globalJsonPath := filepath.Join(componentPath, "global.json")
-	if _, err := os.Stat(globalJsonPath); err == nil {
-		data, err := os.ReadFile(globalJsonPath)
+	globalJsonPath := path.Join(dir, "global.json")
+	if r.fileExists(globalJsonPath) {
+		data, err := fs.ReadFile(r.fsys, globalJsonPath)
 		if err == nil {
 			var globalJson struct {
 				SDK struct {
@@ -227,7 +469,11 @@ func extractDotNetVersions(componentPath string, requirements map[string]string)
 
 			if err := json.Unmarshal(data, &globalJson); err == nil {
 				if globalJson.SDK.Version != "" {
-					requirements["dotnet-sdk"] = globalJson.SDK.Version
+					setVersion(component, "dotnet-sdk", globalJson.SDK.Version, types.Evidence{
+						File:  globalJsonPath,
+						Line:  lineOf(data, `"version"`),
+						Match: "sdk.version",
+					})
 				}
 			}
 		}
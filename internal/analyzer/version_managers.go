@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// toolKeys maps asdf, mise and SDKMAN! tool names to the keys used for
+// manifests. Other tools keep their own name.
+var toolKeys = map[string]string{
+	"nodejs":      "node",
+	"golang":      "go",
+	"dotnet":      "dotnet-sdk",
+	"dotnet-core": "dotnet-sdk",
+}
+
+var versionFiles = []struct {
+	name string
+	key  string
+}{
+	{".node-version", "node"},
+	{".ruby-version", "ruby"},
+	{".java-version", "java"},
+	{".terraform-version", "terraform"},
+}
+
+var miseFiles = []string{"mise.toml", ".mise.toml"} // in increasing precedence
+
+func toolKey(tool string) string {
+	tool = strings.ToLower(strings.TrimPrefix(tool, "core:"))
+	if key, exists := toolKeys[tool]; exists {
+		return key
+	}
+	return tool
+}
+
+// extractVersionManagerVersions reads the files of runtime version managers,
+// which pin exact versions and so take precedence over manifests.
+func (r *repository) extractVersionManagerVersions(dir string, component *types.Component) error {
+	for _, file := range versionFiles {
+		filePath := path.Join(dir, file.name)
+		if !r.fileExists(filePath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, filePath)
+		if err != nil {
+			continue
+		}
+		if version := strings.TrimSpace(string(content)); version != "" {
+			setVersion(component, file.key, version, types.Evidence{File: filePath, Line: 1, Match: version})
+		}
+	}
+
+	sdkmanrcPath := path.Join(dir, ".sdkmanrc")
+	if r.fileExists(sdkmanrcPath) {
+		if content, err := fs.ReadFile(r.fsys, sdkmanrcPath); err == nil {
+			eachSetting(content, func(line int, text string) {
+				tool, version, found := strings.Cut(text, "=")
+				if found && strings.TrimSpace(version) != "" {
+					setVersion(component, toolKey(strings.TrimSpace(tool)), strings.TrimSpace(version),
+						types.Evidence{File: sdkmanrcPath, Line: line, Match: text})
+				}
+			})
+		}
+	}
+
+	toolVersionsPath := path.Join(dir, ".tool-versions")
+	if r.fileExists(toolVersionsPath) {
+		if content, err := fs.ReadFile(r.fsys, toolVersionsPath); err == nil {
+			eachSetting(content, func(line int, text string) {
+				// Further versions are fallbacks for the first one.
+				fields := strings.Fields(text)
+				if len(fields) >= 2 && fields[1] != "system" {
+					setVersion(component, toolKey(fields[0]), fields[1],
+						types.Evidence{File: toolVersionsPath, Line: line, Match: text})
+				}
+			})
+		}
+	}
+
+	for _, name := range miseFiles {
+		misePath := path.Join(dir, name)
+		if !r.fileExists(misePath) {
+			continue
+		}
+		content, err := fs.ReadFile(r.fsys, misePath)
+		if err != nil {
+			continue
+		}
+
+		var mise struct {
+			Tools map[string]any `toml:"tools"`
+		}
+		if toml.Unmarshal(content, &mise) != nil {
+			continue
+		}
+
+		tools := make([]string, 0, len(mise.Tools))
+		for tool := range mise.Tools {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+
+		for _, tool := range tools {
+			// Tools from other backends, such as npm:prettier, are packages
+			// rather than runtimes.
+			if strings.Contains(strings.TrimPrefix(tool, "core:"), ":") {
+				continue
+			}
+			if version := miseVersion(mise.Tools[tool]); version != "" && version != "system" {
+				setVersion(component, toolKey(tool), version, types.Evidence{
+					File:  misePath,
+					Line:  lineOf(content, tool+" ", tool+"=", `"`+tool+`"`),
+					Match: "tools." + tool,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// miseVersion returns the version of a mise tool, which is a string, a list
+// whose first entry is the default, or a table with options.
+func miseVersion(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		if len(v) > 0 {
+			return miseVersion(v[0])
+		}
+	case map[string]any:
+		return miseVersion(v["version"])
+	}
+	return ""
+}
+
+// eachSetting calls fn with the line number and text of every line that is
+// neither empty nor a comment.
+func eachSetting(content []byte, fn func(line int, text string)) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text != "" {
+			fn(line, text)
+		}
+	}
+}
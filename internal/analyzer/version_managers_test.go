@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestExtractVersionManagerVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want map[string]string
+	}{
+		{
+			name: "single-tool files",
+			fsys: fstest.MapFS{
+				".node-version":      &fstest.MapFile{Data: []byte("20.11.0\n")},
+				".ruby-version":      &fstest.MapFile{Data: []byte("3.3.0\n")},
+				".java-version":      &fstest.MapFile{Data: []byte("21\n")},
+				".terraform-version": &fstest.MapFile{Data: []byte("1.7.4\n")},
+			},
+			want: map[string]string{"node": "20.11.0", "ruby": "3.3.0", "java": "21", "terraform": "1.7.4"},
+		},
+		{
+			name: "tool-versions",
+			fsys: fstest.MapFS{
+				".tool-versions": &fstest.MapFile{Data: []byte("# runtimes\nnodejs 20.11.0 18.19.0\ngolang 1.22.1\npython 3.12.2 # latest\nruby system\n")},
+			},
+			want: map[string]string{"node": "20.11.0", "go": "1.22.1", "python": "3.12.2"},
+		},
+		{
+			name: "sdkmanrc",
+			fsys: fstest.MapFS{
+				".sdkmanrc": &fstest.MapFile{Data: []byte("# Enable auto-env\njava=21.0.2-tem\ngradle=8.6\n")},
+			},
+			want: map[string]string{"java": "21.0.2-tem", "gradle": "8.6"},
+		},
+		{
+			name: "mise",
+			fsys: fstest.MapFS{
+				"mise.toml": &fstest.MapFile{Data: []byte(`[env]
+NODE_ENV = "development"
+
+[tools]
+node = "20"
+python = ["3.12", "3.11"]
+"core:go" = "1.22"
+terraform = { version = "1.7.4" }
+"npm:prettier" = "3"
+`)},
+			},
+			want: map[string]string{"node": "20", "python": "3.12", "go": "1.22", "terraform": "1.7.4"},
+		},
+		{
+			name: "invalid mise",
+			fsys: fstest.MapFS{
+				".mise.toml":    &fstest.MapFile{Data: []byte("[tools\n")},
+				".node-version": &fstest.MapFile{Data: []byte("20\n")},
+			},
+			want: map[string]string{"node": "20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractVersionRequirements(tt.fsys, ".")
+			if err != nil {
+				t.Fatalf("ExtractVersionRequirements() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractVersionRequirements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionConflicts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"package.json":   &fstest.MapFile{Data: []byte(`{"name": "web", "engines": {"node": ">=18"}}`)},
+		".nvmrc":         &fstest.MapFile{Data: []byte("18\n")},
+		".node-version":  &fstest.MapFile{Data: []byte("20.11.0\n")},
+		".tool-versions": &fstest.MapFile{Data: []byte("nodejs 20.11.0\nruby 3.3.0\n")},
+		".mise.toml":     &fstest.MapFile{Data: []byte("[tools]\nnode = \"22\"\n")},
+		"index.js":       &fstest.MapFile{Data: []byte("console.log('hello')\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{Evidence: true})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	component := result.Components[0]
+
+	if got := component.VersionRequirements["node"]; got != "22" {
+		t.Errorf("Expected node 22 from .mise.toml, got %q", got)
+	}
+
+	want := []types.VersionConflict{{
+		Key:   "node",
+		Value: "22",
+		File:  ".mise.toml",
+		Overridden: []types.VersionSource{
+			{File: ".nvmrc", Value: "18"},
+			{File: ".tool-versions", Value: "20.11.0"},
+		},
+	}}
+	if !reflect.DeepEqual(component.VersionConflicts, want) {
+		t.Errorf("Unexpected version conflicts:\n got %+v\nwant %+v", component.VersionConflicts, want)
+	}
+
+	for _, e := range component.Evidence {
+		if e.Kind == "version" && e.Name == "node" && (e.File != ".mise.toml" || e.Line != 2) {
+			t.Errorf("Expected node evidence from .mise.toml:2, got %+v", e)
+		}
+	}
+}
+
+func TestCompatibleVersionsDoNotConflict(t *testing.T) {
+	fsys := fstest.MapFS{
+		"package.json": &fstest.MapFile{Data: []byte(`{"name": "web", "engines": {"node": ">=18"}}`)},
+		".nvmrc":       &fstest.MapFile{Data: []byte("18.17.0\n")},
+		"index.js":     &fstest.MapFile{Data: []byte("console.log('hello')\n")},
+	}
+
+	result, err := AnalyzeFS(context.Background(), fsys, "web", &types.AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	component := result.Components[0]
+
+	if got := component.VersionRequirements["node"]; got != "18.17.0" {
+		t.Errorf("Expected node 18.17.0 from .nvmrc, got %q", got)
+	}
+	if len(component.VersionConflicts) != 0 {
+		t.Errorf("Expected no version conflicts, got %+v", component.VersionConflicts)
+	}
+}
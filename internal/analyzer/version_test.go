@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractToolchainVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want map[string]string
+	}{
+		{
+			name: "go toolchain",
+			fsys: fstest.MapFS{
+				"go.mod": &fstest.MapFile{Data: []byte("module example.com/app\n\ngo 1.22\n\ntoolchain go1.22.3\n")},
+			},
+			want: map[string]string{"go": "1.22", "go-toolchain": "1.22.3"},
+		},
+		{
+			name: "default go toolchain",
+			fsys: fstest.MapFS{
+				"go.mod": &fstest.MapFile{Data: []byte("module example.com/app\n\ngo 1.21.0\ntoolchain default\n")},
+			},
+			want: map[string]string{"go": "1.21.0"},
+		},
+		{
+			name: "rust-toolchain.toml",
+			fsys: fstest.MapFS{
+				"Cargo.toml": &fstest.MapFile{Data: []byte("[package]\nname = \"app\"\nrust-version = \"1.74\"\n")},
+				"rust-toolchain.toml": &fstest.MapFile{Data: []byte(`[toolchain]
+channel = "1.76.0"
+components = ["rustfmt", "clippy"]
+targets = ["wasm32-unknown-unknown"]
+profile = "minimal"
+`)},
+			},
+			want: map[string]string{
+				"rust":            "1.74",
+				"rust-channel":    "1.76.0",
+				"rust-components": "rustfmt,clippy",
+				"rust-targets":    "wasm32-unknown-unknown",
+			},
+		},
+		{
+			name: "legacy rust-toolchain wins",
+			fsys: fstest.MapFS{
+				"rust-toolchain":      &fstest.MapFile{Data: []byte("nightly-2024-05-01\n")},
+				"rust-toolchain.toml": &fstest.MapFile{Data: []byte("[toolchain]\nchannel = \"stable\"\n")},
+			},
+			want: map[string]string{"rust-channel": "nightly-2024-05-01"},
+		},
+		{
+			name: "packageManager and volta",
+			fsys: fstest.MapFS{
+				"package.json": &fstest.MapFile{Data: []byte(`{
+  "name": "web",
+  "engines": {"node": ">=18"},
+  "packageManager": "pnpm@9.1.0+sha512.abc123",
+  "volta": {"node": "20.11.0", "pnpm": "8.15.0", "yarn": "1.22.19"}
+}`)},
+			},
+			want: map[string]string{"node": "20.11.0", "pnpm": "9.1.0", "yarn": "1.22.19"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractVersionRequirements(tt.fsys, ".")
+			if err != nil {
+				t.Fatalf("ExtractVersionRequirements() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractVersionRequirements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
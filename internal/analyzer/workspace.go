@@ -0,0 +1,417 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+type workspaceSpec struct {
+	tool        string
+	patterns    []string
+	excludes    []string
+	memberFiles []string
+}
+
+func (r *repository) resolveWorkspaces(components map[string]*types.ComponentInfo) ([]types.Workspace, error) {
+	specs := make(map[string][]*workspaceSpec)
+	for relDir, comp := range components {
+		found, err := r.detectWorkspaces(componentDir(*comp), comp.ConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > 0 {
+			specs[relDir] = found
+			comp.WorkspaceRoot = true
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	members := make(map[*workspaceSpec][]string)
+	for relDir, comp := range components {
+		var owner *workspaceSpec
+		ownerDir := ""
+		for rootDir, rootSpecs := range specs {
+			if rootDir == relDir {
+				continue
+			}
+			for _, spec := range rootSpecs {
+				if !spec.matches(rootDir, relDir, comp.ConfigFiles) {
+					continue
+				}
+				if owner == nil || len(rootDir) > len(ownerDir) ||
+					(len(rootDir) == len(ownerDir) && spec.tool < owner.tool) {
+					owner = spec
+					ownerDir = rootDir
+				}
+			}
+		}
+		if owner != nil {
+			comp.Workspace = workspacePath(ownerDir)
+			members[owner] = append(members[owner], filepath.ToSlash(relDir))
+		}
+	}
+
+	roots := make([]string, 0, len(specs))
+	for rootDir := range specs {
+		roots = append(roots, rootDir)
+	}
+	sort.Strings(roots)
+
+	workspaces := make([]types.Workspace, 0, len(roots))
+	for _, rootDir := range roots {
+		for _, spec := range specs[rootDir] {
+			memberList := members[spec]
+			if memberList == nil {
+				memberList = make([]string, 0)
+			}
+			sort.Strings(memberList)
+			workspaces = append(workspaces, types.Workspace{
+				Tool:    spec.tool,
+				Path:    workspacePath(rootDir),
+				Members: memberList,
+			})
+		}
+	}
+
+	return workspaces, nil
+}
+
+func (r *repository) detectWorkspaces(dir string, configFiles []string) ([]*workspaceSpec, error) {
+	detectors := []struct {
+		configFiles []string
+		detect      func(dir string) (*workspaceSpec, error)
+	}{
+		{[]string{"package.json"}, r.detectJSWorkspace},
+		{[]string{"Cargo.toml"}, r.detectCargoWorkspace},
+		{[]string{"go.work"}, r.detectGoWorkspace},
+		{[]string{"pom.xml"}, r.detectMavenWorkspace},
+		{[]string{"settings.gradle", "settings.gradle.kts"}, r.detectGradleWorkspace},
+	}
+
+	var specs []*workspaceSpec
+	for _, detector := range detectors {
+		applies := false
+		for _, file := range detector.configFiles {
+			if hasConfigFile(configFiles, file) {
+				applies = true
+				break
+			}
+		}
+		if !applies {
+			continue
+		}
+
+		spec, err := detector.detect(dir)
+		if err != nil {
+			return nil, err
+		}
+		if spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].tool < specs[j].tool
+	})
+
+	return specs, nil
+}
+
+func (r *repository) detectJSWorkspace(dir string) (*workspaceSpec, error) {
+	data, err := fs.ReadFile(r.fsys, path.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var packageJson struct {
+		Workspaces     json.RawMessage `json:"workspaces"`
+		PackageManager string          `json:"packageManager"`
+	}
+	if err := json.Unmarshal(data, &packageJson); err != nil {
+		return nil, nil
+	}
+
+	var patterns []string
+	tool := ""
+
+	if content, err := fs.ReadFile(r.fsys, path.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		var pnpmWorkspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(content, &pnpmWorkspace); err == nil {
+			patterns = pnpmWorkspace.Packages
+			tool = "pnpm"
+		}
+	}
+
+	if tool == "" && len(packageJson.Workspaces) > 0 {
+		patterns = parseWorkspacesField(packageJson.Workspaces)
+		switch {
+		case strings.HasPrefix(packageJson.PackageManager, "yarn@"),
+			r.fileExists(path.Join(dir, "yarn.lock")),
+			r.fileExists(path.Join(dir, ".yarnrc.yml")):
+			tool = "yarn"
+		case strings.HasPrefix(packageJson.PackageManager, "pnpm@"):
+			tool = "pnpm"
+		default:
+			tool = "npm"
+		}
+	}
+
+	if tool == "" || len(patterns) == 0 {
+		return nil, nil
+	}
+
+	spec := &workspaceSpec{tool: tool, memberFiles: []string{"package.json"}}
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			spec.excludes = append(spec.excludes, strings.TrimPrefix(pattern, "!"))
+		} else {
+			spec.patterns = append(spec.patterns, pattern)
+		}
+	}
+
+	return spec, nil
+}
+
+func (r *repository) detectCargoWorkspace(dir string) (*workspaceSpec, error) {
+	content, err := fs.ReadFile(r.fsys, path.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := parseCargoManifest(content)
+	if err != nil || manifest.Workspace == nil || len(manifest.Workspace.Members) == 0 {
+		return nil, nil
+	}
+
+	return &workspaceSpec{
+		tool:        "cargo",
+		patterns:    manifest.Workspace.Members,
+		excludes:    manifest.Workspace.Exclude,
+		memberFiles: []string{"Cargo.toml"},
+	}, nil
+}
+
+func (r *repository) detectGoWorkspace(dir string) (*workspaceSpec, error) {
+	content, err := fs.ReadFile(r.fsys, path.Join(dir, "go.work"))
+	if err != nil {
+		return nil, err
+	}
+
+	var uses []string
+	inBlock := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			uses = append(uses, strings.Trim(line, "\"`"))
+		case line == "use (" || line == "use(":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			uses = append(uses, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "use ")), "\"`"))
+		}
+	}
+
+	if len(uses) == 0 {
+		return nil, nil
+	}
+
+	return &workspaceSpec{tool: "go", patterns: uses, memberFiles: []string{"go.mod"}}, nil
+}
+
+func (r *repository) detectMavenWorkspace(dir string) (*workspaceSpec, error) {
+	content, err := fs.ReadFile(r.fsys, path.Join(dir, "pom.xml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pom struct {
+		Modules []string `xml:"modules>module"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil || len(pom.Modules) == 0 {
+		return nil, nil
+	}
+
+	modules := make([]string, 0, len(pom.Modules))
+	for _, module := range pom.Modules {
+		modules = append(modules, strings.TrimSpace(module))
+	}
+
+	return &workspaceSpec{tool: "maven", patterns: modules, memberFiles: []string{"pom.xml"}}, nil
+}
+
+var gradleIncludeRegex = regexp.MustCompile(`(?m)^\s*include\b`)
+var quotedStringRegex = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+func (r *repository) detectGradleWorkspace(dir string) (*workspaceSpec, error) {
+	var content []byte
+	for _, name := range []string{"settings.gradle", "settings.gradle.kts"} {
+		data, err := fs.ReadFile(r.fsys, path.Join(dir, name))
+		if err == nil {
+			content = data
+			break
+		}
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	text := string(content)
+	var projects []string
+	for _, loc := range gradleIncludeRegex.FindAllStringIndex(text, -1) {
+		statement := gradleStatement(text[loc[1]:])
+		for _, match := range quotedStringRegex.FindAllStringSubmatch(statement, -1) {
+			project := match[1] + match[2]
+			project = strings.ReplaceAll(strings.TrimPrefix(project, ":"), ":", "/")
+			if project != "" {
+				projects = append(projects, project)
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		return nil, nil
+	}
+
+	return &workspaceSpec{
+		tool:        "gradle",
+		patterns:    projects,
+		memberFiles: []string{"build.gradle", "build.gradle.kts"},
+	}, nil
+}
+
+// Unparenthesized include calls continue after trailing commas.
+func gradleStatement(rest string) string {
+	trimmed := strings.TrimLeft(rest, " \t")
+	if strings.HasPrefix(trimmed, "(") {
+		if end := strings.Index(trimmed, ")"); end >= 0 {
+			return trimmed[:end]
+		}
+		return trimmed
+	}
+
+	var statement strings.Builder
+	for _, line := range strings.Split(rest, "\n") {
+		statement.WriteString(line)
+		if !strings.HasSuffix(strings.TrimSpace(line), ",") {
+			break
+		}
+	}
+	return statement.String()
+}
+
+// The workspaces field is either a list of globs or, for Yarn classic,
+// an object with a "packages" list.
+func parseWorkspacesField(raw json.RawMessage) []string {
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err == nil {
+		return patterns
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		return object.Packages
+	}
+
+	return nil
+}
+
+func (spec *workspaceSpec) matches(rootDir, relDir string, memberConfigFiles []string) bool {
+	if len(spec.memberFiles) > 0 {
+		hasManifest := false
+		for _, file := range spec.memberFiles {
+			if hasConfigFile(memberConfigFiles, file) {
+				hasManifest = true
+				break
+			}
+		}
+		if !hasManifest {
+			return false
+		}
+	}
+
+	root := filepath.ToSlash(rootDir)
+	dir := filepath.ToSlash(relDir)
+
+	included := false
+	for _, pattern := range spec.patterns {
+		if matchPathGlob(path.Join(root, pattern), dir) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, pattern := range spec.excludes {
+		if matchPathGlob(path.Join(root, pattern), dir) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func workspacePath(relDir string) string {
+	if relDir == "" {
+		return "."
+	}
+	return filepath.ToSlash(relDir)
+}
+
+// "**" matches any number of path segments.
+func matchPathGlob(pattern, name string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if pattern == "" || pattern == "." {
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,396 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IsArchive reports whether name has an archive extension supported by Open.
+func IsArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Members of a tar archive up to maxBufferedSize are kept in memory, as long
+// as they fit in maxBufferedTotal. Larger ones are read from the archive
+// again when they are opened.
+const (
+	maxBufferedSize  = 1 << 20
+	maxBufferedTotal = 64 << 20
+)
+
+// Archive is an opened archive. Close releases the archive file.
+type Archive struct {
+	fs.FS
+	file *os.File
+}
+
+func (a *Archive) Close() error {
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// Open returns the contents of a .tar.gz, .tgz or .zip archive as a
+// read-only file system. When every entry lives below a single top-level
+// directory, as in release tarballs, that directory becomes the root.
+func Open(name string) (*Archive, error) {
+	if !strings.HasSuffix(strings.ToLower(name), ".zip") {
+		fsys, err := ReadTarGz(func() (io.ReadCloser, error) { return os.Open(name) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive %s: %w", name, err)
+		}
+		if fsys, err = stripTopLevelDir(fsys); err != nil {
+			return nil, err
+		}
+		return &Archive{FS: fsys}, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil {
+		var fsys fs.FS
+		if fsys, err = ReadZip(file, info.Size()); err != nil {
+			err = fmt.Errorf("failed to read zip archive %s: %w", name, err)
+		} else if fsys, err = stripTopLevelDir(fsys); err == nil {
+			return &Archive{FS: fsys, file: file}, nil
+		}
+	}
+	file.Close()
+	return nil, err
+}
+
+// ReadTarGz indexes a gzip-compressed tar stream returned by open. Small
+// members are kept in memory; larger ones are streamed from a new stream
+// when they are opened.
+func ReadTarGz(open func() (io.ReadCloser, error)) (fs.FS, error) {
+	file, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	fsys := newMemFS()
+	reader := tar.NewReader(gz)
+	buffered := int64(0)
+	for index := 0; ; index++ {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := cleanName(header.Name)
+		if !ok {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fsys.addDir(name, header.ModTime)
+		case tar.TypeReg:
+			if header.Size < 0 {
+				return nil, fmt.Errorf("invalid size of %s", header.Name)
+			}
+			entry := &memEntry{size: header.Size}
+			if header.Size > maxBufferedSize || buffered+header.Size > maxBufferedTotal {
+				entry.open = tarMember(open, index)
+			} else {
+				entry.data = make([]byte, header.Size)
+				if _, err := io.ReadFull(reader, entry.data); err != nil {
+					return nil, err
+				}
+				buffered += header.Size
+			}
+			fsys.addFile(name, entry, header.FileInfo().Mode(), header.ModTime)
+		}
+	}
+
+	return fsys, nil
+}
+
+// tarMember returns a function that opens the member at index of the tar
+// stream returned by open.
+func tarMember(open func() (io.ReadCloser, error), index int) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		file, err := open()
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		reader := tar.NewReader(gz)
+		for i := 0; i <= index; i++ {
+			if _, err := reader.Next(); err != nil {
+				file.Close()
+				return nil, err
+			}
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, file}, nil
+	}
+}
+
+// ReadZip returns the files of a zip archive, which are read from r as they
+// are opened. Entries whose names escape the archive root are left out.
+func ReadZip(r io.ReaderAt, size int64) (fs.FS, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) {
+		return nil, err
+	}
+
+	files := reader.File[:0]
+	for _, file := range reader.File {
+		if _, ok := cleanName(file.Name); ok {
+			files = append(files, file)
+		}
+	}
+	reader.File = files
+	return reader, nil
+}
+
+// cleanName turns an archive entry name into a valid fs.FS path, rejecting
+// absolute paths and entries that escape the archive root.
+func cleanName(name string) (string, bool) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func stripTopLevelDir(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return fs.Sub(fsys, entries[0].Name())
+	}
+	return fsys, nil
+}
+
+type memEntry struct {
+	name     string
+	data     []byte
+	size     int64
+	open     func() (io.ReadCloser, error) // for members that are not buffered
+	mode     fs.FileMode
+	modTime  time.Time
+	children map[string]*memEntry
+}
+
+// memFS is an in-memory tree built from archive entries. Parent directories
+// that have no entry of their own are created implicitly.
+type memFS struct {
+	root *memEntry
+}
+
+func newMemFS() *memFS {
+	return &memFS{root: &memEntry{name: ".", mode: fs.ModeDir | 0755, children: make(map[string]*memEntry)}}
+}
+
+func (m *memFS) addDir(name string, modTime time.Time) *memEntry {
+	dir := m.root
+	for _, part := range strings.Split(name, "/") {
+		child, exists := dir.children[part]
+		if !exists || child.children == nil {
+			child = &memEntry{name: part, mode: fs.ModeDir | 0755, modTime: modTime, children: make(map[string]*memEntry)}
+			dir.children[part] = child
+		}
+		dir = child
+	}
+	return dir
+}
+
+func (m *memFS) addFile(name string, entry *memEntry, mode fs.FileMode, modTime time.Time) {
+	dir := m.root
+	if parent := path.Dir(name); parent != "." {
+		dir = m.addDir(parent, modTime)
+	}
+	base := path.Base(name)
+	if existing, exists := dir.children[base]; exists && existing.children != nil {
+		return
+	}
+	entry.name, entry.mode, entry.modTime = base, mode.Perm(), modTime
+	dir.children[base] = entry
+}
+
+func (m *memFS) lookup(op, name string) (*memEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry := m.root
+	if name == "." {
+		return entry, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		child, exists := entry.children[part]
+		if !exists {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		entry = child
+	}
+	return entry, nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	entry, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.children != nil {
+		return &memDir{entry: entry, path: name}, nil
+	}
+	if entry.open != nil {
+		rc, err := entry.open()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &memFile{entry: entry, reader: rc, closer: rc}, nil
+	}
+	return &memFile{entry: entry, reader: bytes.NewReader(entry.data)}, nil
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	entry, err := m.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.children != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("is a directory")}
+	}
+	if entry.open != nil {
+		file, err := m.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	return bytes.Clone(entry.data), nil
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.children == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return entry.dirEntries(), nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	entry, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (e *memEntry) dirEntries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(e.children))
+	for _, child := range e.children {
+		entries = append(entries, child)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries
+}
+
+func (e *memEntry) Name() string               { return e.name }
+func (e *memEntry) Size() int64                { return e.size }
+func (e *memEntry) Mode() fs.FileMode          { return e.mode }
+func (e *memEntry) ModTime() time.Time         { return e.modTime }
+func (e *memEntry) IsDir() bool                { return e.children != nil }
+func (e *memEntry) Sys() any                   { return nil }
+func (e *memEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *memEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+type memFile struct {
+	entry  *memEntry
+	reader io.Reader
+	closer io.Closer
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *memFile) Read(p []byte) (int, error) { return f.reader.Read(p) }
+
+func (f *memFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+type memDir struct {
+	entry   *memEntry
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries = d.entry.dirEntries()
+	}
+
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
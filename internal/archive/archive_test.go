@@ -0,0 +1,177 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var archiveFiles = map[string]string{
+	"app-1.0/package.json":     `{"name": "app"}`,
+	"app-1.0/src/index.js":     "console.log('app');\n",
+	"app-1.0/api/go.mod":       "module example.com/api\n",
+	"app-1.0/api/cmd/main.go":  "package main\n",
+	"app-1.0/../../etc/passwd": "escaped",
+}
+
+func buildTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openBytes(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"app.tar.gz": buildTarGz(t, archiveFiles),
+		"app.tgz":    buildTarGz(t, archiveFiles),
+		"app.zip":    buildZip(t, archiveFiles),
+	}
+
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(dir, name)
+			if err := os.WriteFile(archivePath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			fsys, err := Open(archivePath)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer fsys.Close()
+
+			if err := fstest.TestFS(fsys, "package.json", "src/index.js", "api/go.mod", "api/cmd/main.go"); err != nil {
+				t.Errorf("TestFS() error = %v", err)
+			}
+
+			content, err := fs.ReadFile(fsys, "api/go.mod")
+			if err != nil || string(content) != "module example.com/api\n" {
+				t.Errorf("api/go.mod = %q, %v", content, err)
+			}
+		})
+	}
+}
+
+func TestOpenKeepsMultipleTopLevelEntries(t *testing.T) {
+	fsys, err := ReadTarGz(openBytes(buildTarGz(t, map[string]string{
+		"frontend/package.json": "{}",
+		"backend/go.mod":        "module backend\n",
+	})))
+	if err != nil {
+		t.Fatalf("ReadTarGz() error = %v", err)
+	}
+
+	fsys, err = stripTopLevelDir(fsys)
+	if err != nil {
+		t.Fatalf("stripTopLevelDir() error = %v", err)
+	}
+	if _, err := fs.Stat(fsys, "frontend/package.json"); err != nil {
+		t.Errorf("Expected frontend/package.json at the root: %v", err)
+	}
+}
+
+func TestReadTarGzStreamsLargeMembers(t *testing.T) {
+	large := strings.Repeat("x", maxBufferedSize+1)
+	fsys, err := ReadTarGz(openBytes(buildTarGz(t, map[string]string{
+		"dist/bundle.js": large,
+		"package.json":   "{}",
+	})))
+	if err != nil {
+		t.Fatalf("ReadTarGz() error = %v", err)
+	}
+
+	entry, err := fsys.(*memFS).lookup("stat", "dist/bundle.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.data != nil || entry.Size() != int64(len(large)) {
+		t.Errorf("Expected an unbuffered member of %d bytes, got %d buffered bytes and size %d", len(large), len(entry.data), entry.Size())
+	}
+
+	content, err := fs.ReadFile(fsys, "dist/bundle.js")
+	if err != nil || string(content) != large {
+		t.Errorf("dist/bundle.js = %d bytes, %v", len(content), err)
+	}
+	if content, err := fs.ReadFile(fsys, "package.json"); err != nil || string(content) != "{}" {
+		t.Errorf("package.json = %q, %v", content, err)
+	}
+}
+
+func TestReadTarGzRejectsTruncatedMembers(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "huge.bin", Mode: 0644, Size: 1 << 40}); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+
+	if _, err := ReadTarGz(openBytes(buf.Bytes())); err == nil {
+		t.Error("Expected an error for a member larger than the archive")
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	tests := map[string]bool{
+		"repo.tar.gz": true,
+		"repo.TGZ":    true,
+		"repo.zip":    true,
+		"repo.tar":    false,
+		"repo":        false,
+	}
+	for name, expected := range tests {
+		if IsArchive(name) != expected {
+			t.Errorf("IsArchive(%s) = %v, want %v", name, !expected, expected)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package attributes reads the linguist attributes teams set in
+// .gitattributes files to correct how their files are classified.
+package attributes
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/replyzer/analyze-repo/internal/ignore"
+)
+
+// File is the name of the per-directory attributes file.
+const File = ".gitattributes"
+
+// Linguist holds the linguist attributes of a file. A nil field is not
+// specified and leaves the decision to the built-in heuristics.
+type Linguist struct {
+	Vendored      *bool
+	Generated     *bool
+	Documentation *bool
+	// Language is the value of linguist-language, or empty if not specified.
+	Language string
+}
+
+// rule is a line of an attributes file. attrs maps each linguist attribute
+// the line sets to "true", "false" or its value, and to "" for attributes it
+// resets to unspecified.
+type rule struct {
+	pattern ignore.Pattern
+	attrs   map[string]string
+}
+
+// parseRules parses gitattributes-formatted content, keeping only linguist
+// attributes. Patterns are relative to base, the slash-separated directory
+// containing the file ("" for the root). Negative patterns are not allowed by
+// git and are skipped.
+func parseRules(content []byte, base string) []rule {
+	var rules []rule
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+
+		attrs := make(map[string]string)
+		for _, field := range fields[1:] {
+			switch {
+			case strings.HasPrefix(field, "-"):
+				attrs[field[1:]] = "false"
+			case strings.HasPrefix(field, "!"):
+				attrs[field[1:]] = ""
+			case strings.Contains(field, "="):
+				name, value, _ := strings.Cut(field, "=")
+				attrs[name] = value
+			default:
+				attrs[field] = "true"
+			}
+		}
+		for name := range attrs {
+			if !strings.HasPrefix(name, "linguist-") {
+				delete(attrs, name)
+			}
+		}
+		if len(attrs) == 0 {
+			continue
+		}
+
+		for _, pattern := range ignore.ParsePatterns([]byte(fields[0]), base) {
+			rules = append(rules, rule{pattern: pattern, attrs: attrs})
+		}
+	}
+	return rules
+}
+
+// Matcher looks up the linguist attributes of paths inside an fs.FS, loading
+// the attributes file of each directory the first time a path below it is
+// looked up. It is safe for concurrent use.
+type Matcher struct {
+	fsys fs.FS
+	// info holds the rules of .git/info/attributes, which take precedence
+	// over all attributes files.
+	info []rule
+
+	mu   sync.Mutex
+	dirs map[string][]rule
+}
+
+// NewMatcher returns a Matcher for the attributes files in fsys.
+func NewMatcher(fsys fs.FS) *Matcher {
+	m := &Matcher{fsys: fsys, dirs: make(map[string][]rule)}
+	if content, err := fs.ReadFile(fsys, ".git/info/attributes"); err == nil {
+		m.info = parseRules(content, "")
+	}
+	return m
+}
+
+// Linguist returns the linguist attributes of the file at relPath. As in
+// git, attributes files deeper in the tree take precedence over those closer
+// to the root, and later lines over earlier ones. Patterns only match files,
+// so attributes of a directory do not apply to its contents; use dir/** for
+// that.
+func (m *Matcher) Linguist(relPath string) Linguist {
+	relPath = strings.Trim(path.Clean(relPath), "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dirs := []string{""}
+	if parent := path.Dir(relPath); parent != "." {
+		parts := strings.Split(parent, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	attrs := make(map[string]string)
+	apply := func(rules []rule) {
+		for _, rule := range rules {
+			if !rule.pattern.Match(relPath, false) {
+				continue
+			}
+			for name, value := range rule.attrs {
+				if value == "" {
+					delete(attrs, name)
+				} else {
+					attrs[name] = value
+				}
+			}
+		}
+	}
+	for _, dir := range dirs {
+		apply(m.rulesFor(dir))
+	}
+	apply(m.info)
+
+	return Linguist{
+		Vendored:      boolAttr(attrs, "linguist-vendored"),
+		Generated:     boolAttr(attrs, "linguist-generated"),
+		Documentation: boolAttr(attrs, "linguist-documentation"),
+		Language:      attrs["linguist-language"],
+	}
+}
+
+func boolAttr(attrs map[string]string, name string) *bool {
+	value, set := attrs[name]
+	if !set {
+		return nil
+	}
+	b := value != "false"
+	return &b
+}
+
+func (m *Matcher) rulesFor(dir string) []rule {
+	if rules, loaded := m.dirs[dir]; loaded {
+		return rules
+	}
+
+	filePath := File
+	if dir != "" {
+		filePath = dir + "/" + File
+	}
+
+	var rules []rule
+	if content, err := fs.ReadFile(m.fsys, filePath); err == nil {
+		rules = parseRules(content, dir)
+	}
+
+	m.dirs[dir] = rules
+	return rules
+}
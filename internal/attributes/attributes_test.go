@@ -0,0 +1,66 @@
+package attributes
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLinguist(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitattributes": {Data: []byte(`# comments and other attributes are ignored
+*.pb.go linguist-generated=true diff=golang
+assets/** linguist-vendored
+assets/app.js -linguist-vendored
+*.tmpl linguist-language=HTML
+docs/ linguist-documentation
+!*.md linguist-documentation
+`)},
+		"services/api/.gitattributes": {Data: []byte("*.pb.go !linguist-generated\nlegacy/*.js linguist-vendored=false linguist-documentation\n")},
+		".git/info/attributes":        {Data: []byte("assets/app.js linguist-language=TypeScript\n")},
+	}
+	m := NewMatcher(fsys)
+
+	yes, no := true, false
+	tests := []struct {
+		path string
+		want Linguist
+	}{
+		{"proto/user.pb.go", Linguist{Generated: &yes}},
+		{"services/api/user.pb.go", Linguist{}},
+		{"assets/lib/jquery.js", Linguist{Vendored: &yes}},
+		{"assets/app.js", Linguist{Vendored: &no, Language: "TypeScript"}},
+		{"web/page.tmpl", Linguist{Language: "HTML"}},
+		{"docs/guide.txt", Linguist{}},
+		{"README.md", Linguist{}},
+		{"services/api/legacy/old.js", Linguist{Vendored: &no, Documentation: &yes}},
+		{"services/web/legacy/old.js", Linguist{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := m.Linguist(tt.path)
+			if !equalBool(got.Vendored, tt.want.Vendored) || !equalBool(got.Generated, tt.want.Generated) ||
+				!equalBool(got.Documentation, tt.want.Documentation) || got.Language != tt.want.Language {
+				t.Errorf("Linguist(%q) = %s, want %s", tt.path, format(got), format(tt.want))
+			}
+		})
+	}
+}
+
+func equalBool(a, b *bool) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func format(l Linguist) string {
+	s := func(b *bool) string {
+		if b == nil {
+			return "unspecified"
+		}
+		if *b {
+			return "true"
+		}
+		return "false"
+	}
+	return "{vendored: " + s(l.Vendored) + ", generated: " + s(l.Generated) +
+		", documentation: " + s(l.Documentation) + ", language: " + l.Language + "}"
+}
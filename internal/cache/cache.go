@@ -0,0 +1,249 @@
+// Package cache keeps per-file analysis results on disk between runs, so that
+// re-analyzing a repository only re-processes the files that changed.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// version is bumped whenever the cached results change meaning.
+const version = 7
+
+var fileName = regexp.MustCompile(`^[0-9a-f]{16}\.json(\.tmp\d*)?$`)
+
+// Dir returns $REPLYZER_CACHE_DIR, or replyzer in the user cache directory.
+func Dir() (string, error) {
+	if dir := os.Getenv("REPLYZER_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "replyzer"), nil
+}
+
+type entry struct {
+	Size    int64                      `json:"size"`
+	ModTime int64                      `json:"mtime"`
+	Hash    string                     `json:"hash"`
+	Results map[string]json.RawMessage `json:"results"`
+
+	verified bool // known to match the file in this run
+}
+
+type cacheFile struct {
+	Version int               `json:"version"`
+	Root    string            `json:"root"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// Cache holds the per-file results of one repository. A nil Cache caches
+// nothing.
+type Cache struct {
+	file string
+	root string
+	// Files modified at or after the cache was written may have changed
+	// again within the timestamp resolution, so they are verified by hash.
+	written int64
+
+	mu           sync.Mutex
+	entries      map[string]*entry
+	dirty        bool
+	hits, misses int
+}
+
+// Open loads the cache of the repository at root from dir, or returns an
+// empty cache.
+func Open(dir, root string) *Cache {
+	sum := sha256.Sum256([]byte(root))
+	c := &Cache{
+		file:    filepath.Join(dir, hex.EncodeToString(sum[:8])+".json"),
+		root:    root,
+		entries: make(map[string]*entry),
+	}
+
+	info, err := os.Stat(c.file)
+	if err != nil {
+		return c
+	}
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		return c
+	}
+
+	var stored cacheFile
+	if json.Unmarshal(data, &stored) != nil || stored.Version != version || stored.Root != root {
+		return c
+	}
+	for name, e := range stored.Entries {
+		if e != nil {
+			c.entries[name] = e
+		}
+	}
+	c.written = info.ModTime().UnixNano()
+	return c
+}
+
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get decodes the cached result of kind for name into v. Without a hash, the
+// entry must match the size and modification time in info.
+func (c *Cache) Get(name string, info fs.FileInfo, hash, kind string, v any) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.entries[name]
+	if !exists || e.Results[kind] == nil {
+		return c.miss(hash)
+	}
+
+	if hash == "" {
+		if e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() || (!e.verified && e.ModTime >= c.written) {
+			return false
+		}
+	} else if e.Hash != hash {
+		return c.miss(hash)
+	}
+
+	if json.Unmarshal(e.Results[kind], v) != nil {
+		return c.miss(hash)
+	}
+
+	if e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() {
+		e.Size, e.ModTime = info.Size(), info.ModTime().UnixNano()
+		c.dirty = true
+	}
+	e.verified = true
+	c.hits++
+	return true
+}
+
+// A lookup only misses once the content hash did not match either.
+func (c *Cache) miss(hash string) bool {
+	if hash != "" {
+		c.misses++
+	}
+	return false
+}
+
+func (c *Cache) Put(name string, info fs.FileInfo, hash, kind string, v any) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.entries[name]
+	if !exists || e.Hash != hash {
+		e = &entry{Hash: hash, Results: make(map[string]json.RawMessage)}
+		c.entries[name] = e
+	}
+	e.Size, e.ModTime = info.Size(), info.ModTime().UnixNano()
+	e.Results[kind] = data
+	e.verified = true
+	c.dirty = true
+}
+
+func (c *Cache) Stats() (hits, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheFile{Version: version, Root: c.root, Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Concurrent runs must never read a partially written cache.
+	tmp, err := os.CreateTemp(filepath.Dir(c.file), filepath.Base(c.file)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.file); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Clean removes the caches of all repositories from dir and returns how many
+// were removed.
+func Clean(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !fileName.MatchString(e.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove cache: %w", err)
+		}
+		removed++
+	}
+
+	// Only succeeds when nothing else is left in the directory.
+	os.Remove(dir)
+	return removed, nil
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string, modTime time.Time) fs.FileInfo {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestCache(t *testing.T) {
+	dir, repo := t.TempDir(), t.TempDir()
+	file := filepath.Join(repo, "main.go")
+	past := time.Now().Add(-time.Hour)
+	info := writeFile(t, file, "package main\n", past)
+
+	c := Open(dir, repo)
+	var language string
+	if c.Get("main.go", info, "", "language", &language) {
+		t.Fatal("Expected an empty cache")
+	}
+	c.Put("main.go", info, Hash([]byte("package main\n")), "language", "Go")
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	c = Open(dir, repo)
+	if !c.Get("main.go", info, "", "language", &language) || language != "Go" {
+		t.Errorf("Expected the result to be reused by size and mtime, got %q", language)
+	}
+	if c.Get("main.go", info, "", "dependencies", &language) {
+		t.Error("Expected results of other kinds not to be returned")
+	}
+
+	// Touching the file keeps the result as long as the content is the same.
+	touched := writeFile(t, file, "package main\n", past.Add(time.Minute))
+	if c.Get("main.go", touched, "", "language", &language) {
+		t.Error("Expected a changed mtime to require the content hash")
+	}
+	if !c.Get("main.go", touched, Hash([]byte("package main\n")), "language", &language) {
+		t.Error("Expected an unchanged content hash to reuse the result")
+	}
+	if !c.Get("main.go", touched, "", "language", &language) {
+		t.Error("Expected the entry to be updated to the new mtime")
+	}
+
+	changed := writeFile(t, file, "package other\n", past.Add(2*time.Minute))
+	if c.Get("main.go", changed, Hash([]byte("package other\n")), "language", &language) {
+		t.Error("Expected changed content not to reuse the result")
+	}
+	if hits, misses := c.Stats(); hits != 3 || misses != 1 {
+		t.Errorf("Stats() = %d, %d, want 3, 1", hits, misses)
+	}
+
+	if c := Open(dir, t.TempDir()); len(c.entries) != 0 {
+		t.Error("Expected each repository to have its own cache")
+	}
+}
+
+func TestCacheRacyEntries(t *testing.T) {
+	dir, repo := t.TempDir(), t.TempDir()
+	info := writeFile(t, filepath.Join(repo, "a.txt"), "a", time.Now().Add(time.Hour))
+
+	c := Open(dir, repo)
+	c.Put("a.txt", info, Hash([]byte("a")), "language", "Text")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var language string
+	c = Open(dir, repo)
+	if c.Get("a.txt", info, "", "language", &language) {
+		t.Error("Expected entries not older than the cache to be verified by hash")
+	}
+	if !c.Get("a.txt", info, Hash([]byte("a")), "language", &language) || language != "Text" {
+		t.Errorf("Expected the hash to verify the entry, got %q", language)
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	info := writeFile(t, filepath.Join(t.TempDir(), "a"), "a", time.Now())
+
+	for _, repo := range []string{"/a", "/b"} {
+		c := Open(dir, repo)
+		c.Put("a", info, Hash([]byte("a")), "language", "Text")
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := writeFile(t, filepath.Join(dir, "notes.json"), "{}", time.Now())
+
+	removed, err := Clean(dir)
+	if err != nil || removed != 2 {
+		t.Errorf("Clean() = %d, %v, want 2, nil", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, other.Name())); err != nil {
+		t.Errorf("Expected other files to be kept: %v", err)
+	}
+
+	if removed, err := Clean(filepath.Join(dir, "missing")); removed != 0 || err != nil {
+		t.Errorf("Clean() of a missing directory = %d, %v", removed, err)
+	}
+}
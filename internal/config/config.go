@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFiles are the names of the per-repository configuration file, in the
+// order they are looked up. Only the first one found is read.
+var RepoFiles = []string{".replyzer.yaml", ".replyzer.yml"}
+
+// Config holds the analysis settings that can be set in configuration files
+// and environment variables.
+type Config struct {
+	Format            string                    `yaml:"format,omitempty"`
+	Exclude           []string                  `yaml:"exclude,omitempty"`
+	Rules             []string                  `yaml:"rules,omitempty"`
+	DisabledDetectors []string                  `yaml:"disable_detectors,omitempty"`
+	Components        []types.ComponentOverride `yaml:"components,omitempty"`
+
+	// Files are the configuration files that were read, lowest precedence
+	// first.
+	Files []string `yaml:"-"`
+}
+
+// UserFile returns the path of the user-level configuration file:
+// $REPLYZER_CONFIG if set, otherwise replyzer/config.yaml in the user
+// configuration directory.
+func UserFile() (string, error) {
+	if file := os.Getenv("REPLYZER_CONFIG"); file != "" {
+		return file, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "replyzer", "config.yaml"), nil
+}
+
+// Load reads the user configuration and the configuration of the repository
+// in repoDir, which takes precedence. Missing files are skipped; repoDir may
+// be empty when there is no repository directory to read from.
+func Load(repoDir string) (*Config, error) {
+	cfg := &Config{}
+
+	if file, err := UserFile(); err == nil {
+		userCfg, err := ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if userCfg != nil {
+			cfg.Merge(userCfg)
+		}
+	}
+
+	if repoDir == "" {
+		return cfg, nil
+	}
+
+	for _, name := range RepoFiles {
+		repoCfg, err := ReadFile(filepath.Join(repoDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cfg.Merge(repoCfg)
+		break
+	}
+
+	return cfg, nil
+}
+
+// ReadFile reads a configuration file. Relative rules paths are resolved
+// against the directory of the file.
+func ReadFile(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+
+	for i, rulesFile := range cfg.Rules {
+		if !filepath.IsAbs(rulesFile) {
+			cfg.Rules[i] = filepath.Join(filepath.Dir(file), rulesFile)
+		}
+	}
+	cfg.Files = []string{file}
+	return cfg, nil
+}
+
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// FromEnv reads the REPLYZER_* environment variables. List values are
+// comma-separated.
+func FromEnv(getenv func(string) string) *Config {
+	return &Config{
+		Format:            getenv("REPLYZER_FORMAT"),
+		Exclude:           splitList(getenv("REPLYZER_EXCLUDE")),
+		Rules:             splitList(getenv("REPLYZER_RULES")),
+		DisabledDetectors: splitList(getenv("REPLYZER_DISABLE_DETECTOR")),
+	}
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Merge applies the settings of other, which takes precedence. Lists that
+// are set in other replace the current ones, except for component
+// overrides, which are appended so that the later ones win.
+func (c *Config) Merge(other *Config) {
+	if other.Format != "" {
+		c.Format = other.Format
+	}
+	if other.Exclude != nil {
+		c.Exclude = other.Exclude
+	}
+	if other.Rules != nil {
+		c.Rules = other.Rules
+	}
+	if other.DisabledDetectors != nil {
+		c.DisabledDetectors = other.DisabledDetectors
+	}
+	c.Components = append(c.Components, other.Components...)
+	c.Files = append(c.Files, other.Files...)
+}
+
+func (c *Config) validate() error {
+	switch c.Format {
+	case "", "yaml", "json":
+	default:
+		return fmt.Errorf("unsupported format: %s", c.Format)
+	}
+
+	for i, override := range c.Components {
+		if override.Path == "" {
+			return fmt.Errorf("component override %d has no path", i+1)
+		}
+		if _, err := path.Match(filepath.ToSlash(override.Path), ""); err != nil {
+			return fmt.Errorf("component override %s has an invalid path pattern: %w", override.Path, err)
+		}
+		if override.Type == "" && override.Framework == "" {
+			return fmt.Errorf("component override %s needs a type or framework", override.Path)
+		}
+	}
+	return nil
+}
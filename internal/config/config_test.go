@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func writeConfig(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	userFile := filepath.Join(home, "replyzer", "config.yaml")
+	t.Setenv("REPLYZER_CONFIG", userFile)
+	writeConfig(t, userFile, `format: json
+exclude: [examples]
+disable_detectors: [env-files]
+components:
+  - path: "tools/*"
+    type: cli-tool
+`)
+
+	repoDir := t.TempDir()
+	writeConfig(t, filepath.Join(repoDir, ".replyzer.yaml"), `exclude: [legacy, "sandbox/*"]
+rules: [ci/rules.yaml]
+components:
+  - path: api
+    framework: InHouseRPC
+  - path: tools/gen
+    type: library
+`)
+
+	cfg, err := Load(repoDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Format != "json" {
+		t.Errorf("Expected the user format, got %q", cfg.Format)
+	}
+	if !reflect.DeepEqual(cfg.Exclude, []string{"legacy", "sandbox/*"}) {
+		t.Errorf("Expected the repository excludes to win, got %v", cfg.Exclude)
+	}
+	if !reflect.DeepEqual(cfg.DisabledDetectors, []string{"env-files"}) {
+		t.Errorf("Expected the user disabled detectors, got %v", cfg.DisabledDetectors)
+	}
+	if !reflect.DeepEqual(cfg.Rules, []string{filepath.Join(repoDir, "ci", "rules.yaml")}) {
+		t.Errorf("Expected rules relative to the config file, got %v", cfg.Rules)
+	}
+
+	wantComponents := []types.ComponentOverride{
+		{Path: "tools/*", Type: "cli-tool"},
+		{Path: "api", Framework: "InHouseRPC"},
+		{Path: "tools/gen", Type: "library"},
+	}
+	if !reflect.DeepEqual(cfg.Components, wantComponents) {
+		t.Errorf("Unexpected component overrides: %+v", cfg.Components)
+	}
+	if !reflect.DeepEqual(cfg.Files, []string{userFile, filepath.Join(repoDir, ".replyzer.yaml")}) {
+		t.Errorf("Unexpected config files: %v", cfg.Files)
+	}
+
+	cfg.Merge(FromEnv(func(name string) string {
+		return map[string]string{
+			"REPLYZER_FORMAT":  "yaml",
+			"REPLYZER_EXCLUDE": "docs, ,vendor",
+		}[name]
+	}))
+	if cfg.Format != "yaml" || !reflect.DeepEqual(cfg.Exclude, []string{"docs", "vendor"}) {
+		t.Errorf("Expected environment variables to win, got %q %v", cfg.Format, cfg.Exclude)
+	}
+	if len(cfg.Rules) != 1 {
+		t.Errorf("Expected unset environment variables to keep the rules, got %v", cfg.Rules)
+	}
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	t.Setenv("REPLYZER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("Expected an empty config, got %+v", cfg)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", "excludes: [docs]\n", "field excludes not found"},
+		{"bad format", "format: toml\n", "unsupported format: toml"},
+		{"missing path", "components:\n  - type: library\n", "has no path"},
+		{"empty override", "components:\n  - path: api\n", "needs a type or framework"},
+		{"bad pattern", "components:\n  - path: \"[\"\n    type: library\n", "invalid path pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type IndexEntry struct {
+	Path string
+	Mode uint32
+	Size uint32
+	Hash []byte
+}
+
+const (
+	indexSignature   = "DIRC"
+	indexExtended    = 0x4000
+	indexNameMask    = 0x0fff
+	modeSparseDir    = 0040000
+	entryFixedLength = 40
+)
+
+var ErrNotRepository = errors.New("not a git repository")
+
+// FindRepository returns the git directory and working tree containing dir.
+func FindRepository(dir string) (gitDir, workTree string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".git")
+		info, statErr := os.Stat(candidate)
+		if statErr == nil {
+			if info.IsDir() {
+				return candidate, dir, nil
+			}
+			gitDir, err := readGitFile(candidate)
+			if err != nil {
+				return "", "", err
+			}
+			return gitDir, dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// Worktrees and submodules use a .git file pointing at the real git directory.
+func readGitFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid gitdir file %s", path)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+func hashSize(gitDir string) int {
+	content, err := os.ReadFile(filepath.Join(commonDir(gitDir), "config"))
+	if err != nil {
+		return 20
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if found && strings.EqualFold(strings.TrimSpace(key), "objectformat") &&
+			strings.EqualFold(strings.TrimSpace(value), "sha256") {
+			return 32
+		}
+	}
+	return 20
+}
+
+// commonDir resolves the directory shared by all worktrees of a repository.
+func commonDir(gitDir string) string {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+
+	dir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return dir
+}
+
+func ExcludeFile(gitDir string) string {
+	return filepath.Join(commonDir(gitDir), "info", "exclude")
+}
+
+// ReadIndex supports index versions 2, 3 and 4.
+func ReadIndex(gitDir string) ([]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "index"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseIndex(data, hashSize(gitDir))
+}
+
+func parseIndex(data []byte, hashLen int) ([]IndexEntry, error) {
+	if len(data) < 12 || string(data[:4]) != indexSignature {
+		return nil, errors.New("invalid index signature")
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+
+	entries := make([]IndexEntry, 0, count)
+	offset := 12
+	previousPath := ""
+	for i := uint32(0); i < count; i++ {
+		start := offset
+		if offset+entryFixedLength+hashLen+2 > len(data) {
+			return nil, errors.New("truncated index entry")
+		}
+
+		mode := binary.BigEndian.Uint32(data[offset+24 : offset+28])
+		size := binary.BigEndian.Uint32(data[offset+36 : offset+40])
+		offset += entryFixedLength
+
+		hash := make([]byte, hashLen)
+		copy(hash, data[offset:offset+hashLen])
+		offset += hashLen
+
+		flags := binary.BigEndian.Uint16(data[offset : offset+2])
+		offset += 2
+		if version >= 3 && flags&indexExtended != 0 {
+			offset += 2
+		}
+
+		var name string
+		if version == 4 {
+			strip, n := readOffsetVarint(data[offset:])
+			if n == 0 || strip > len(previousPath) {
+				return nil, errors.New("invalid path prefix in index entry")
+			}
+			offset += n
+
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, errors.New("unterminated index entry path")
+			}
+			name = previousPath[:len(previousPath)-strip] + string(data[offset:offset+end])
+			offset += end + 1
+		} else {
+			nameLen := int(flags & indexNameMask)
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 || (nameLen < indexNameMask && end != nameLen) {
+				return nil, errors.New("invalid index entry path")
+			}
+			name = string(data[offset : offset+end])
+
+			// Entries are NUL padded to a multiple of eight bytes.
+			entryLen := offset - start + end + 1
+			offset = start + (entryLen+7)/8*8
+		}
+
+		previousPath = name
+		entries = append(entries, IndexEntry{Path: name, Mode: mode, Size: size, Hash: hash})
+	}
+
+	return entries, nil
+}
+
+// readOffsetVarint decodes the varints of index v4 paths and pack offsets.
+func readOffsetVarint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	value := int(data[0] & 0x7f)
+	n := 1
+	for data[n-1]&0x80 != 0 {
+		if n >= len(data) {
+			return 0, 0
+		}
+		value = ((value + 1) << 7) | int(data[n]&0x7f)
+		n++
+	}
+	return value, n
+}
+
+// TrackedFiles returns the tracked files relative to dir. Directories of a
+// sparse index have a trailing slash.
+func TrackedFiles(dir string) ([]string, error) {
+	gitDir, workTree, err := FindRepository(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := filepath.Rel(workTree, absDir)
+	if err != nil {
+		return nil, err
+	}
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." {
+		prefix = ""
+	} else {
+		prefix += "/"
+	}
+
+	entries, err := ReadIndex(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Path, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(entry.Path, prefix)
+		if entry.Mode == modeSparseDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+
+	return files, nil
+}
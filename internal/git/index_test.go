@@ -0,0 +1,128 @@
+package git
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// encodeIndex builds an index file in the given format version.
+func encodeIndex(version uint32, paths []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(indexSignature)
+	binary.Write(&buf, binary.BigEndian, version)
+	binary.Write(&buf, binary.BigEndian, uint32(len(paths)))
+
+	previous := ""
+	for _, name := range paths {
+		start := buf.Len()
+		buf.Write(make([]byte, 24))
+		binary.Write(&buf, binary.BigEndian, uint32(0100644))
+		buf.Write(make([]byte, 8))
+		binary.Write(&buf, binary.BigEndian, uint32(len(name)))
+		buf.Write(bytes.Repeat([]byte{0xab}, 20))
+		binary.Write(&buf, binary.BigEndian, uint16(len(name)))
+
+		if version == 4 {
+			common := 0
+			for common < len(previous) && common < len(name) && previous[common] == name[common] {
+				common++
+			}
+			buf.WriteByte(byte(len(previous) - common))
+			buf.WriteString(name[common:])
+			buf.WriteByte(0)
+		} else {
+			buf.WriteString(name)
+			entryLen := buf.Len() - start + 1
+			buf.Write(make([]byte, (entryLen+7)/8*8-entryLen+1))
+		}
+		previous = name
+	}
+
+	buf.Write(make([]byte, 20))
+	return buf.Bytes()
+}
+
+func TestParseIndex(t *testing.T) {
+	paths := []string{".env.example", "apps/web/package.json", "apps/web/src/index.ts", "go.mod"}
+
+	for _, version := range []uint32{2, 3, 4} {
+		entries, err := parseIndex(encodeIndex(version, paths), 20)
+		if err != nil {
+			t.Fatalf("parseIndex(v%d) error = %v", version, err)
+		}
+
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Path)
+		}
+		if !reflect.DeepEqual(got, paths) {
+			t.Errorf("parseIndex(v%d) paths = %v, want %v", version, got, paths)
+		}
+		if entries[1].Size != uint32(len(paths[1])) {
+			t.Errorf("parseIndex(v%d) size = %d, want %d", version, entries[1].Size, len(paths[1]))
+		}
+	}
+}
+
+func TestParseIndexRejectsInvalidData(t *testing.T) {
+	if _, err := parseIndex([]byte("NOPE"), 20); err == nil {
+		t.Error("Expected an error for an invalid signature")
+	}
+
+	truncated := encodeIndex(2, []string{"main.go"})[:30]
+	if _, err := parseIndex(truncated, 20); err == nil {
+		t.Error("Expected an error for a truncated index")
+	}
+}
+
+func TestTrackedFiles(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".git")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	index := encodeIndex(2, []string{"README.md", "services/api/go.mod", "services/api/main.go"})
+	if err := os.WriteFile(filepath.Join(gitDir, "index"), index, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	subdir := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(subdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := TrackedFiles(subdir)
+	if err != nil {
+		t.Fatalf("TrackedFiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"go.mod", "main.go"}) {
+		t.Errorf("TrackedFiles() = %v", files)
+	}
+}
+
+func TestFindRepositoryWithGitFile(t *testing.T) {
+	root := t.TempDir()
+	realGitDir := filepath.Join(root, "actual-git-dir")
+	workTree := filepath.Join(root, "worktree")
+	if err := os.MkdirAll(realGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(workTree, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workTree, ".git"), []byte("gitdir: ../actual-git-dir\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gitDir, foundWorkTree, err := FindRepository(workTree)
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	if gitDir != realGitDir || foundWorkTree != workTree {
+		t.Errorf("FindRepository() = %s, %s", gitDir, foundWorkTree)
+	}
+}
@@ -0,0 +1,329 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	objectCommit   = 1
+	objectTree     = 2
+	objectBlob     = 3
+	objectTag      = 4
+	objectOfsDelta = 6
+	objectRefDelta = 7
+)
+
+var objectTypeNames = map[int]string{
+	objectCommit: "commit",
+	objectTree:   "tree",
+	objectBlob:   "blob",
+	objectTag:    "tag",
+}
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Repository reads objects and references directly from a .git directory.
+type Repository struct {
+	gitDir    string
+	commonDir string
+	workTree  string
+	hashLen   int
+
+	packsOnce sync.Once
+	packs     []*packFile
+	packsErr  error
+}
+
+func OpenRepository(dir string) (*Repository, error) {
+	gitDir, workTree, err := FindRepository(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repository{
+		gitDir:    gitDir,
+		commonDir: commonDir(gitDir),
+		workTree:  workTree,
+		hashLen:   hashSize(gitDir),
+	}, nil
+}
+
+// WorkTree returns the top-level directory of the repository.
+func (r *Repository) WorkTree() string {
+	return r.workTree
+}
+
+// Close releases the pack files opened while reading objects.
+func (r *Repository) Close() error {
+	for _, pack := range r.packs {
+		pack.close()
+	}
+	return nil
+}
+
+func (r *Repository) readObject(hash string) (string, []byte, error) {
+	objectType, data, err := r.readLooseObject(hash)
+	if err == nil {
+		return objectType, data, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != r.hashLen {
+		return "", nil, fmt.Errorf("invalid object id %q", hash)
+	}
+
+	packs, err := r.loadPacks()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, pack := range packs {
+		if offset, found := pack.find(raw); found {
+			typeCode, data, err := pack.readAt(r, offset)
+			if err != nil {
+				return "", nil, err
+			}
+			return objectTypeNames[typeCode], data, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+}
+
+// objectSize reads the size from the object header without inflating the
+// content.
+func (r *Repository) objectSize(hash string) (int64, error) {
+	size, err := r.looseObjectSize(hash)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return size, err
+	}
+
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != r.hashLen {
+		return 0, fmt.Errorf("invalid object id %q", hash)
+	}
+
+	packs, err := r.loadPacks()
+	if err != nil {
+		return 0, err
+	}
+	for _, pack := range packs {
+		if offset, found := pack.find(raw); found {
+			return pack.sizeAt(offset)
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+}
+
+func (r *Repository) looseObjectSize(hash string) (int64, error) {
+	if len(hash) < 3 {
+		return 0, os.ErrNotExist
+	}
+
+	file, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:]))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader, err := zlib.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	header, err := bufio.NewReaderSize(io.LimitReader(reader, 64), 64).ReadSlice(0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid object header in %s", hash)
+	}
+	_, sizeText, _ := strings.Cut(string(header[:len(header)-1]), " ")
+	size, err := strconv.ParseInt(sizeText, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid object size in %s", hash)
+	}
+	return size, nil
+}
+
+func (r *Repository) readLooseObject(hash string) (string, []byte, error) {
+	if len(hash) < 3 {
+		return "", nil, os.ErrNotExist
+	}
+
+	file, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:]))
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	reader, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+
+	header, data, found := bytes.Cut(content, []byte{0})
+	if !found {
+		return "", nil, fmt.Errorf("invalid object header in %s", hash)
+	}
+	objectType, sizeText, _ := strings.Cut(string(header), " ")
+	if size, err := strconv.Atoi(sizeText); err != nil || size != len(data) {
+		return "", nil, fmt.Errorf("invalid object size in %s", hash)
+	}
+
+	return objectType, data, nil
+}
+
+func (r *Repository) loadPacks() ([]*packFile, error) {
+	r.packsOnce.Do(func() {
+		indexes, err := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+		if err != nil {
+			r.packsErr = err
+			return
+		}
+		for _, index := range indexes {
+			pack, err := openPackIndex(index, r.hashLen)
+			if err != nil {
+				r.packsErr = err
+				return
+			}
+			r.packs = append(r.packs, pack)
+		}
+	})
+	return r.packs, r.packsErr
+}
+
+// findAbbreviated resolves a unique object id from a hex prefix.
+func (r *Repository) findAbbreviated(prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	matches := make(map[string]bool)
+
+	entries, _ := os.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+	for _, entry := range entries {
+		hash := prefix[:2] + entry.Name()
+		if strings.HasPrefix(hash, prefix) {
+			matches[hash] = true
+		}
+	}
+
+	packs, err := r.loadPacks()
+	if err != nil {
+		return "", err
+	}
+	for _, pack := range packs {
+		for _, hash := range pack.withPrefix(prefix) {
+			matches[hash] = true
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+	case 1:
+		for hash := range matches {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("ambiguous object id %s", prefix)
+}
+
+// applyDelta reconstructs an object from its base and a git delta.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, n := readSizeVarint(delta)
+	if n == 0 || baseSize != len(base) {
+		return nil, errors.New("delta base size mismatch")
+	}
+	delta = delta[n:]
+
+	resultSize, n := readSizeVarint(delta)
+	if n == 0 {
+		return nil, errors.New("invalid delta result size")
+	}
+	delta = delta[n:]
+
+	// The result size is only trusted as far as the delta could produce it.
+	result := make([]byte, 0, min(resultSize, len(base)+len(delta)))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			var offset, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta copy")
+					}
+					offset |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta copy")
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, errors.New("delta copy out of range")
+			}
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			size := int(op)
+			if size > len(delta) {
+				return nil, errors.New("truncated delta insert")
+			}
+			result = append(result, delta[:size]...)
+			delta = delta[size:]
+		default:
+			return nil, errors.New("invalid delta opcode")
+		}
+		if len(result) > resultSize {
+			return nil, errors.New("delta result size mismatch")
+		}
+	}
+
+	if len(result) != resultSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return result, nil
+}
+
+// readSizeVarint decodes the little-endian base-128 sizes used in deltas.
+func readSizeVarint(data []byte) (int, int) {
+	value := 0
+	shift := 0
+	for i, b := range data {
+		value |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
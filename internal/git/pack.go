@@ -0,0 +1,354 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"container/list"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// packFile reads objects of a version 2 pack on demand.
+type packFile struct {
+	path    string
+	hashLen int
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []int64
+
+	openOnce sync.Once
+	file     *os.File
+	size     int64
+	openErr  error
+
+	cache objectCache
+}
+
+func openPackIndex(indexPath string, hashLen int) (*packFile, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) ||
+		binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("unsupported pack index %s", indexPath)
+	}
+
+	pack := &packFile{
+		path:    strings.TrimSuffix(indexPath, ".idx") + ".pack",
+		hashLen: hashLen,
+	}
+	for i := 0; i < 256; i++ {
+		pack.fanout[i] = binary.BigEndian.Uint32(data[8+i*4:])
+	}
+
+	count := int(pack.fanout[255])
+	hashStart := 8 + 256*4
+	offsetStart := hashStart + count*hashLen + count*4
+	largeStart := offsetStart + count*4
+	if len(data) < largeStart {
+		return nil, fmt.Errorf("truncated pack index %s", indexPath)
+	}
+
+	pack.hashes = data[hashStart : hashStart+count*hashLen]
+	pack.offsets = make([]int64, count)
+	for i := 0; i < count; i++ {
+		offset := binary.BigEndian.Uint32(data[offsetStart+i*4:])
+		if offset&0x80000000 == 0 {
+			pack.offsets[i] = int64(offset)
+			continue
+		}
+
+		large := largeStart + int(offset&0x7fffffff)*8
+		if large+8 > len(data) {
+			return nil, fmt.Errorf("truncated pack index %s", indexPath)
+		}
+		pack.offsets[i] = int64(binary.BigEndian.Uint64(data[large:]))
+	}
+
+	return pack, nil
+}
+
+func (p *packFile) hashAt(i int) []byte {
+	return p.hashes[i*p.hashLen : (i+1)*p.hashLen]
+}
+
+func (p *packFile) find(hash []byte) (int64, bool) {
+	lo := 0
+	if hash[0] > 0 {
+		lo = int(p.fanout[hash[0]-1])
+	}
+	hi := int(p.fanout[hash[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashAt(lo+i), hash) >= 0
+	})
+	if i < hi && bytes.Equal(p.hashAt(i), hash) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+func (p *packFile) withPrefix(prefix string) []string {
+	var matches []string
+	count := int(p.fanout[255])
+	for i := 0; i < count; i++ {
+		hash := hex.EncodeToString(p.hashAt(i))
+		if strings.HasPrefix(hash, prefix) {
+			matches = append(matches, hash)
+		}
+	}
+	return matches
+}
+
+func (p *packFile) open() (*os.File, error) {
+	p.openOnce.Do(func() {
+		p.file, p.openErr = os.Open(p.path)
+		if p.openErr != nil {
+			return
+		}
+		var info os.FileInfo
+		if info, p.openErr = p.file.Stat(); p.openErr == nil {
+			p.size = info.Size()
+		}
+	})
+	return p.file, p.openErr
+}
+
+func (p *packFile) close() {
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+// maxDeflateRatio bounds the sizes object headers can claim for the bytes
+// left in the pack.
+const maxDeflateRatio = 1032
+
+func (p *packFile) readAt(repo *Repository, offset int64) (int, []byte, error) {
+	if typeCode, data, cached := p.cache.get(offset); cached {
+		return typeCode, bytes.Clone(data), nil
+	}
+	return p.decode(repo, offset)
+}
+
+// Bases are shared with the cache and must not be modified.
+func (p *packFile) readBase(repo *Repository, offset int64) (int, []byte, error) {
+	if typeCode, data, cached := p.cache.get(offset); cached {
+		return typeCode, data, nil
+	}
+	typeCode, data, err := p.decode(repo, offset)
+	if err == nil {
+		p.cache.add(offset, typeCode, data)
+	}
+	return typeCode, data, err
+}
+
+type packHeader struct {
+	typeCode   int
+	size       int // for deltas, the size of the delta itself
+	dataOffset int64
+	baseOffset int64
+	baseHash   []byte
+}
+
+func (p *packFile) readHeader(offset int64) (packHeader, error) {
+	file, err := p.open()
+	if err != nil {
+		return packHeader{}, err
+	}
+
+	header := make([]byte, 64)
+	n, err := file.ReadAt(header, offset)
+	if err != nil && err != io.EOF {
+		return packHeader{}, err
+	}
+	header = header[:n]
+	if len(header) == 0 {
+		return packHeader{}, errors.New("invalid pack offset")
+	}
+
+	h := packHeader{typeCode: int(header[0]>>4) & 7, size: int(header[0] & 0x0f)}
+	shift := 4
+	pos := 1
+	for header[pos-1]&0x80 != 0 {
+		if pos >= len(header) || shift > 56 {
+			return packHeader{}, errors.New("invalid pack object header")
+		}
+		h.size |= int(header[pos]&0x7f) << shift
+		shift += 7
+		pos++
+	}
+
+	switch h.typeCode {
+	case objectOfsDelta:
+		distance, n := readOffsetVarint(header[pos:])
+		if n == 0 || int64(distance) <= 0 || int64(distance) > offset {
+			return packHeader{}, errors.New("invalid delta offset")
+		}
+		h.baseOffset = offset - int64(distance)
+		pos += n
+	case objectRefDelta:
+		if pos+p.hashLen > len(header) {
+			return packHeader{}, errors.New("invalid delta base")
+		}
+		h.baseHash = header[pos : pos+p.hashLen]
+		pos += p.hashLen
+	}
+
+	h.dataOffset = offset + int64(pos)
+	remaining := p.size - h.dataOffset
+	if h.size < 0 || remaining <= 0 || int64(h.size) > remaining*maxDeflateRatio {
+		return packHeader{}, fmt.Errorf("invalid size %d of pack object at offset %d", h.size, offset)
+	}
+	return h, nil
+}
+
+func (p *packFile) inflate(h packHeader) (io.ReadCloser, error) {
+	return zlib.NewReader(io.NewSectionReader(p.file, h.dataOffset, p.size-h.dataOffset))
+}
+
+// For a delta, only its start is inflated to read the size of the result.
+func (p *packFile) sizeAt(offset int64) (int64, error) {
+	h, err := p.readHeader(offset)
+	if err != nil {
+		return 0, err
+	}
+	if h.typeCode != objectOfsDelta && h.typeCode != objectRefDelta {
+		return int64(h.size), nil
+	}
+
+	reader, err := p.inflate(h)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	// The delta starts with the base and result sizes, at most ten bytes each.
+	sizes := make([]byte, min(h.size, 20))
+	if _, err := io.ReadFull(reader, sizes); err != nil {
+		return 0, fmt.Errorf("failed to inflate pack object: %w", err)
+	}
+	_, n := readSizeVarint(sizes)
+	if n == 0 {
+		return 0, errors.New("invalid delta base size")
+	}
+	resultSize, m := readSizeVarint(sizes[n:])
+	if m == 0 {
+		return 0, errors.New("invalid delta result size")
+	}
+	return int64(resultSize), nil
+}
+
+func (p *packFile) decode(repo *Repository, offset int64) (int, []byte, error) {
+	h, err := p.readHeader(offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	reader, err := p.inflate(h)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer reader.Close()
+
+	data := make([]byte, h.size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return 0, nil, fmt.Errorf("failed to inflate pack object: %w", err)
+	}
+
+	typeCode := h.typeCode
+	switch typeCode {
+	case objectOfsDelta:
+		baseType, base, err := p.readBase(repo, h.baseOffset)
+		if err != nil {
+			return 0, nil, err
+		}
+		if data, err = applyDelta(base, data); err != nil {
+			return 0, nil, err
+		}
+		typeCode = baseType
+	case objectRefDelta:
+		baseTypeName, base, err := repo.readObject(hex.EncodeToString(h.baseHash))
+		if err != nil {
+			return 0, nil, err
+		}
+		if data, err = applyDelta(base, data); err != nil {
+			return 0, nil, err
+		}
+		typeCode = 0
+		for code, name := range objectTypeNames {
+			if name == baseTypeName {
+				typeCode = code
+			}
+		}
+		if typeCode == 0 {
+			return 0, nil, fmt.Errorf("invalid delta base type %s", baseTypeName)
+		}
+	}
+
+	return typeCode, data, nil
+}
+
+// maxCachedBytes bounds the delta bases kept by a pack.
+const maxCachedBytes = 32 << 20
+
+// objectCache is an LRU cache of decoded objects by pack offset.
+type objectCache struct {
+	mu      sync.Mutex
+	entries map[int64]*list.Element
+	order   list.List
+	bytes   int
+}
+
+type cachedObject struct {
+	offset   int64
+	typeCode int
+	data     []byte
+}
+
+func (c *objectCache) get(offset int64) (int, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.entries[offset]
+	if !found {
+		return 0, nil, false
+	}
+	c.order.MoveToFront(element)
+	object := element.Value.(*cachedObject)
+	return object.typeCode, object.data, true
+}
+
+func (c *objectCache) add(offset int64, typeCode int, data []byte) {
+	if len(data) > maxCachedBytes/4 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[int64]*list.Element)
+	}
+	if _, found := c.entries[offset]; found {
+		return
+	}
+	c.entries[offset] = c.order.PushFront(&cachedObject{offset: offset, typeCode: typeCode, data: data})
+	c.bytes += len(data)
+
+	for c.bytes > maxCachedBytes {
+		oldest := c.order.Back()
+		object := c.order.Remove(oldest).(*cachedObject)
+		delete(c.entries, object.offset)
+		c.bytes -= len(object.data)
+	}
+}
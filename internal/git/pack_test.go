@@ -0,0 +1,72 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAtRejectsOversizedObject(t *testing.T) {
+	var body bytes.Buffer
+	zw := zlib.NewWriter(&body)
+	zw.Write([]byte("tiny"))
+	zw.Close()
+
+	// A blob header claiming 2^53 bytes, followed by a few compressed bytes.
+	header := []byte{0x80 | objectBlob<<4, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	packPath := filepath.Join(t.TempDir(), "corrupt.pack")
+	if err := os.WriteFile(packPath, append(header, body.Bytes()...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pack := &packFile{path: packPath}
+	defer pack.close()
+	if _, _, err := pack.readAt(nil, 0); err == nil {
+		t.Error("Expected an error for an object larger than the pack could hold")
+	}
+}
+
+func TestObjectCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var cache objectCache
+	chunk := make([]byte, maxCachedBytes/4)
+
+	for offset := int64(0); offset < 4; offset++ {
+		cache.add(offset, objectBlob, chunk)
+	}
+	if _, _, found := cache.get(0); !found {
+		t.Fatal("Expected offset 0 to be cached")
+	}
+	cache.add(4, objectBlob, chunk)
+
+	if _, _, found := cache.get(1); found {
+		t.Error("Expected the least recently used offset 1 to be evicted")
+	}
+	for _, offset := range []int64{0, 2, 3, 4} {
+		if _, _, found := cache.get(offset); !found {
+			t.Errorf("Expected offset %d to be cached", offset)
+		}
+	}
+}
+
+func TestPackedDeltaChains(t *testing.T) {
+	root := setupHistory(t)
+	repo, err := OpenRepository(root)
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	for _, rev := range []string{"v1.0.0", "HEAD~1", "v1.0.0"} {
+		fsys, _, err := repo.TreeFS(rev)
+		if err != nil {
+			t.Fatalf("TreeFS(%s) error = %v", rev, err)
+		}
+		data, err := fsys.ReadFile("src/index.js")
+		if err != nil || !bytes.HasSuffix(data, []byte("console.log('v1');\n")) {
+			t.Errorf("src/index.js at %s = %d bytes, %v", rev, len(data), err)
+		}
+		data[0] = 'X' // callers own the returned data, even when it is cached
+	}
+}
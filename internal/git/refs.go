@@ -0,0 +1,231 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type commit struct {
+	tree    string
+	parents []string
+	time    time.Time
+}
+
+// ResolveRevision resolves a branch, tag, commit id or abbreviated commit id,
+// optionally followed by ~N and ^N ancestry suffixes, to a commit id.
+func (r *Repository) ResolveRevision(rev string) (string, error) {
+	base, suffix := rev, ""
+	if idx := strings.IndexAny(rev, "~^"); idx >= 0 {
+		base, suffix = rev[:idx], rev[idx:]
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+
+	hash, err := r.resolveName(base)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err = r.peelToCommit(hash)
+	if err != nil {
+		return "", err
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		count := 1
+		if digits > 0 {
+			count, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+
+		switch op {
+		case '~':
+			for i := 0; i < count; i++ {
+				if hash, err = r.parent(hash, 1); err != nil {
+					return "", fmt.Errorf("invalid revision %s: %w", rev, err)
+				}
+			}
+		case '^':
+			if count == 0 {
+				continue
+			}
+			if hash, err = r.parent(hash, count); err != nil {
+				return "", fmt.Errorf("invalid revision %s: %w", rev, err)
+			}
+		default:
+			return "", fmt.Errorf("invalid revision %s", rev)
+		}
+	}
+
+	return hash, nil
+}
+
+func (r *Repository) resolveName(name string) (string, error) {
+	if isHex(name) && len(name) == r.hashLen*2 {
+		return strings.ToLower(name), nil
+	}
+
+	candidates := []string{
+		name,
+		"refs/" + name,
+		"refs/tags/" + name,
+		"refs/heads/" + name,
+		"refs/remotes/" + name,
+		"refs/remotes/" + name + "/HEAD",
+	}
+	for _, candidate := range candidates {
+		if hash, err := r.readRef(candidate, 0); err == nil {
+			return hash, nil
+		}
+	}
+
+	if isHex(name) && len(name) >= 4 {
+		return r.findAbbreviated(name)
+	}
+
+	return "", fmt.Errorf("unknown revision %s", name)
+}
+
+func (r *Repository) readRef(name string, depth int) (string, error) {
+	if depth > 10 {
+		return "", fmt.Errorf("symbolic reference loop at %s", name)
+	}
+
+	// HEAD and other pseudo refs are per worktree; everything else is shared.
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+
+		value := strings.TrimSpace(string(content))
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		if isHex(value) && len(value) == r.hashLen*2 {
+			return value, nil
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("unknown reference %s", name)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		hash, refName, found := strings.Cut(line, " ")
+		if found && refName == name {
+			return hash, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown reference %s", name)
+}
+
+// peelToCommit follows annotated tags until it reaches a commit.
+func (r *Repository) peelToCommit(hash string) (string, error) {
+	for i := 0; i < 10; i++ {
+		objectType, data, err := r.readObject(hash)
+		if err != nil {
+			return "", err
+		}
+
+		switch objectType {
+		case "commit":
+			return hash, nil
+		case "tag":
+			target := headerValue(data, "object")
+			if target == "" {
+				return "", fmt.Errorf("invalid tag object %s", hash)
+			}
+			hash = target
+		default:
+			return "", fmt.Errorf("%s is a %s, not a commit", hash, objectType)
+		}
+	}
+	return "", fmt.Errorf("too many nested tags at %s", hash)
+}
+
+func (r *Repository) readCommit(hash string) (*commit, error) {
+	objectType, data, err := r.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != "commit" {
+		return nil, fmt.Errorf("%s is a %s, not a commit", hash, objectType)
+	}
+
+	c := &commit{}
+	headers, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree = value
+		case "parent":
+			c.parents = append(c.parents, value)
+		case "committer":
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				if seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64); err == nil {
+					c.time = time.Unix(seconds, 0).UTC()
+				}
+			}
+		}
+	}
+
+	if c.tree == "" {
+		return nil, fmt.Errorf("commit %s has no tree", hash)
+	}
+	return c, nil
+}
+
+func (r *Repository) parent(hash string, n int) (string, error) {
+	c, err := r.readCommit(hash)
+	if err != nil {
+		return "", err
+	}
+	if n > len(c.parents) {
+		return "", fmt.Errorf("commit %s has no parent %d", hash, n)
+	}
+	return c.parents[n-1], nil
+}
+
+func headerValue(data []byte, key string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, key+" "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	if len(s)%2 == 1 {
+		_, err = hex.DecodeString(s + "0")
+	}
+	return s != "" && err == nil
+}
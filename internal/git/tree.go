@@ -0,0 +1,322 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	modeTree    = 0040000
+	modeSymlink = 0120000
+	modeGitlink = 0160000
+	modeExec    = 0100755
+)
+
+type treeEntry struct {
+	name string
+	mode uint32
+	hash string
+}
+
+// TreeFS is a read-only fs.FS of a commit's tree, read lazily.
+type TreeFS struct {
+	repo    *Repository
+	root    string
+	modTime time.Time
+
+	mu    sync.Mutex
+	trees map[string][]treeEntry
+	sizes map[string]int64
+}
+
+// TreeFS returns the tree of rev and its commit id.
+func (r *Repository) TreeFS(rev string) (*TreeFS, string, error) {
+	hash, err := r.ResolveRevision(rev)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := r.readCommit(hash)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &TreeFS{
+		repo:    r,
+		root:    c.tree,
+		modTime: c.time,
+		trees:   make(map[string][]treeEntry),
+		sizes:   make(map[string]int64),
+	}, hash, nil
+}
+
+func (t *TreeFS) readTree(hash string) ([]treeEntry, error) {
+	t.mu.Lock()
+	entries, cached := t.trees[hash]
+	t.mu.Unlock()
+	if cached {
+		return entries, nil
+	}
+
+	objectType, data, err := t.repo.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != "tree" {
+		return nil, fmt.Errorf("%s is a %s, not a tree", hash, objectType)
+	}
+
+	for len(data) > 0 {
+		header, rest, found := bytes.Cut(data, []byte{0})
+		if !found || len(rest) < t.repo.hashLen {
+			return nil, fmt.Errorf("invalid tree object %s", hash)
+		}
+		modeText, name, _ := strings.Cut(string(header), " ")
+		mode, err := strconv.ParseUint(modeText, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tree entry mode in %s", hash)
+		}
+
+		entries = append(entries, treeEntry{
+			name: name,
+			mode: uint32(mode),
+			hash: hex.EncodeToString(rest[:t.repo.hashLen]),
+		})
+		data = rest[t.repo.hashLen:]
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	t.mu.Lock()
+	t.trees[hash] = entries
+	t.mu.Unlock()
+	return entries, nil
+}
+
+func (t *TreeFS) lookup(op, name string) (treeEntry, error) {
+	if !fs.ValidPath(name) {
+		return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry := treeEntry{name: ".", mode: modeTree, hash: t.root}
+	if name == "." {
+		return entry, nil
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if entry.mode != modeTree {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		entries, err := t.readTree(entry.hash)
+		if err != nil {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		i := sort.Search(len(entries), func(i int) bool {
+			return entries[i].name >= part
+		})
+		if i == len(entries) || entries[i].name != part {
+			return treeEntry{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		entry = entries[i]
+	}
+
+	return entry, nil
+}
+
+func (t *TreeFS) readBlob(entry treeEntry) ([]byte, error) {
+	objectType, data, err := t.repo.readObject(entry.hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != "blob" {
+		return nil, fmt.Errorf("%s is a %s, not a blob", entry.hash, objectType)
+	}
+
+	t.mu.Lock()
+	t.sizes[entry.hash] = int64(len(data))
+	t.mu.Unlock()
+	return data, nil
+}
+
+func (t *TreeFS) blobSize(entry treeEntry) int64 {
+	t.mu.Lock()
+	size, cached := t.sizes[entry.hash]
+	t.mu.Unlock()
+	if cached {
+		return size
+	}
+
+	size, err := t.repo.objectSize(entry.hash)
+	if err != nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	t.sizes[entry.hash] = size
+	t.mu.Unlock()
+	return size
+}
+
+func (t *TreeFS) Open(name string) (fs.File, error) {
+	entry, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &treeFileInfo{fsys: t, entry: entry}
+	if info.IsDir() {
+		return &treeDir{fsys: t, info: info, path: name}, nil
+	}
+
+	data, err := t.readBlob(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &treeFile{info: info, reader: bytes.NewReader(data)}, nil
+}
+
+func (t *TreeFS) ReadFile(name string) ([]byte, error) {
+	entry, err := t.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.mode == modeTree || entry.mode == modeGitlink {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+
+	data, err := t.readBlob(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+func (t *TreeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := t.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch entry.mode {
+	case modeGitlink:
+		// Submodule contents are not part of this repository's objects.
+		return []fs.DirEntry{}, nil
+	case modeTree:
+	default:
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+
+	entries, err := t.readTree(entry.hash)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	dirEntries := make([]fs.DirEntry, 0, len(entries))
+	for _, child := range entries {
+		dirEntries = append(dirEntries, &treeFileInfo{fsys: t, entry: child})
+	}
+	return dirEntries, nil
+}
+
+func (t *TreeFS) Stat(name string) (fs.FileInfo, error) {
+	entry, err := t.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return &treeFileInfo{fsys: t, entry: entry}, nil
+}
+
+type treeFileInfo struct {
+	fsys  *TreeFS
+	entry treeEntry
+}
+
+func (i *treeFileInfo) Name() string { return i.entry.name }
+
+func (i *treeFileInfo) Size() int64 {
+	if i.IsDir() {
+		return 0
+	}
+	return i.fsys.blobSize(i.entry)
+}
+
+func (i *treeFileInfo) Mode() fs.FileMode {
+	switch i.entry.mode {
+	case modeTree, modeGitlink:
+		return fs.ModeDir | 0755
+	case modeSymlink:
+		return fs.ModeSymlink | 0777
+	case modeExec:
+		return 0755
+	default:
+		return 0644
+	}
+}
+
+func (i *treeFileInfo) ModTime() time.Time         { return i.fsys.modTime }
+func (i *treeFileInfo) IsDir() bool                { return i.Mode().IsDir() }
+func (i *treeFileInfo) Sys() any                   { return nil }
+func (i *treeFileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i *treeFileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type treeFile struct {
+	info   *treeFileInfo
+	reader *bytes.Reader
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Read(p []byte) (int, error) { return f.reader.Read(p) }
+func (f *treeFile) Close() error               { return nil }
+
+type treeDir struct {
+	fsys    *TreeFS
+	info    *treeFileInfo
+	path    string
+	entries []fs.DirEntry
+	offset  int
+	loaded  bool
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDir) Close() error               { return nil }
+
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fmt.Errorf("is a directory")}
+}
+
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fsys.ReadDir(d.path)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.loaded = true
+	}
+
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
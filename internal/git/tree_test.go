@@ -0,0 +1,226 @@
+package git
+
+import (
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func gitCommand(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	fullPath := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupHistory creates a repository whose first release is packed (with
+// deltas) and whose latest commit is stored as loose objects.
+func setupHistory(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	gitCommand(t, root, "init", "-q", "-b", "main")
+
+	base := strings.Repeat("console.log('shared line of the bundle');\n", 200)
+	writeFile(t, root, "package.json", `{"name": "app", "engines": {"node": ">=16"}}`)
+	writeFile(t, root, "src/index.js", base)
+	gitCommand(t, root, "add", ".")
+	gitCommand(t, root, "commit", "-q", "-m", "first")
+
+	writeFile(t, root, "src/index.js", base+"console.log('v1');\n")
+	writeFile(t, root, "docs/guide.md", "# Guide\n")
+	gitCommand(t, root, "add", ".")
+	gitCommand(t, root, "commit", "-q", "-m", "release")
+	gitCommand(t, root, "tag", "-a", "v1.0.0", "-m", "release v1.0.0")
+	gitCommand(t, root, "gc", "-q", "--aggressive")
+
+	writeFile(t, root, "package.json", `{"name": "app", "engines": {"node": ">=20"}}`)
+	writeFile(t, root, "src/index.js", base+"console.log('v2');\n")
+	gitCommand(t, root, "rm", "-q", "docs/guide.md")
+	gitCommand(t, root, "add", ".")
+	gitCommand(t, root, "commit", "-q", "-m", "next")
+
+	return root
+}
+
+func TestTreeFS(t *testing.T) {
+	root := setupHistory(t)
+
+	repo, err := OpenRepository(root)
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	tests := []struct {
+		rev         string
+		packageJson string
+		hasGuide    bool
+	}{
+		{"v1.0.0", `{"name": "app", "engines": {"node": ">=16"}}`, true},
+		{"main", `{"name": "app", "engines": {"node": ">=20"}}`, false},
+		{"HEAD~1", `{"name": "app", "engines": {"node": ">=16"}}`, true},
+		{"HEAD^", `{"name": "app", "engines": {"node": ">=16"}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rev, func(t *testing.T) {
+			fsys, hash, err := repo.TreeFS(tt.rev)
+			if err != nil {
+				t.Fatalf("TreeFS(%s) error = %v", tt.rev, err)
+			}
+
+			expectedHash := gitCommand(t, root, "rev-parse", tt.rev+"^{commit}")
+			if hash != expectedHash {
+				t.Errorf("TreeFS(%s) commit = %s, want %s", tt.rev, hash, expectedHash)
+			}
+
+			data, err := fs.ReadFile(fsys, "package.json")
+			if err != nil || string(data) != tt.packageJson {
+				t.Errorf("package.json = %q, %v", data, err)
+			}
+
+			index, err := fs.ReadFile(fsys, "src/index.js")
+			if err != nil || !strings.HasPrefix(string(index), "console.log") {
+				t.Errorf("src/index.js could not be read: %v", err)
+			}
+
+			_, err = fs.Stat(fsys, "docs/guide.md")
+			if (err == nil) != tt.hasGuide {
+				t.Errorf("docs/guide.md exists = %v, want %v", err == nil, tt.hasGuide)
+			}
+
+			expected := []string{"package.json", "src/index.js"}
+			if tt.hasGuide {
+				expected = append(expected, "docs/guide.md")
+			}
+			if err := fstest.TestFS(fsys, expected...); err != nil {
+				t.Errorf("TestFS() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveRevision(t *testing.T) {
+	root := setupHistory(t)
+
+	repo, err := OpenRepository(root)
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	head := gitCommand(t, root, "rev-parse", "HEAD")
+	for _, rev := range []string{"HEAD", "main", "refs/heads/main", head, head[:10]} {
+		hash, err := repo.ResolveRevision(rev)
+		if err != nil || hash != head {
+			t.Errorf("ResolveRevision(%s) = %s, %v; want %s", rev, hash, err, head)
+		}
+	}
+
+	if _, err := repo.ResolveRevision("does-not-exist"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello, world")
+	// base size 12, result size 12, copy 7 bytes from offset 0, insert "there".
+	delta := []byte{12, 12, 0x90, 7, 5, 't', 'h', 'e', 'r', 'e'}
+
+	result, err := applyDelta(base, delta)
+	if err != nil {
+		t.Fatalf("applyDelta() error = %v", err)
+	}
+	if string(result) != "hello, there" {
+		t.Errorf("applyDelta() = %q", result)
+	}
+}
+
+func TestTreeFSStatDoesNotReadBlobs(t *testing.T) {
+	root := setupHistory(t)
+
+	// Incompressible content, so that the truncated object below still holds
+	// its header but not the rest of the blob.
+	bundle := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(bundle)
+	writeFile(t, root, "dist/bundle.bin", string(bundle))
+	gitCommand(t, root, "add", ".")
+	gitCommand(t, root, "commit", "-q", "-m", "bundle")
+
+	hash := gitCommand(t, root, "rev-parse", "HEAD:dist/bundle.bin")
+	objectPath := filepath.Join(root, ".git", "objects", hash[:2], hash[2:])
+	if err := os.Chmod(objectPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(objectPath, 4096); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := OpenRepository(root)
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	fsys, _, err := repo.TreeFS("HEAD")
+	if err != nil {
+		t.Fatalf("TreeFS() error = %v", err)
+	}
+
+	info, err := fs.Stat(fsys, "dist/bundle.bin")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != int64(len(bundle)) {
+		t.Errorf("Size() = %d, want %d", info.Size(), len(bundle))
+	}
+	if _, err := fs.ReadFile(fsys, "dist/bundle.bin"); err == nil {
+		t.Error("Expected reading the truncated blob to fail, so Stat cannot have read it")
+	}
+
+	// Packed objects, including deltas, report the size of their content.
+	fsys, _, err = repo.TreeFS("v1.0.0")
+	if err != nil {
+		t.Fatalf("TreeFS() error = %v", err)
+	}
+	for _, name := range []string{"package.json", "src/index.js", "docs/guide.md"} {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			t.Fatalf("Stat(%s) error = %v", name, err)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if info.Size() != int64(len(data)) {
+			t.Errorf("Size(%s) = %d, want %d", name, info.Size(), len(data))
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package ignore
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Files lists the per-directory ignore files, in increasing precedence.
+var Files = []string{".gitignore", ".replyzerignore"}
+
+type Pattern struct {
+	base     string
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// ParsePatterns parses gitignore-formatted content. Patterns are relative to
+// base, the slash-separated directory containing the file ("" for the root).
+func ParsePatterns(content []byte, base string) []Pattern {
+	var patterns []Pattern
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if pattern, ok := parsePattern(line, base); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func parsePattern(line, base string) (Pattern, bool) {
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+
+	pattern := Pattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false
+	}
+
+	// A slash anywhere but at the end anchors the pattern to its directory;
+	// otherwise it matches a name at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	pattern.segments = strings.Split(line, "/")
+	if !anchored {
+		pattern.segments = append([]string{"**"}, pattern.segments...)
+	}
+
+	return pattern, true
+}
+
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return strings.ReplaceAll(line, `\ `, " ")
+}
+
+// Match reports whether the pattern matches relPath, a slash-separated path
+// relative to the repository root.
+func (p Pattern) Match(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if p.base != "" {
+		if !strings.HasPrefix(relPath, p.base+"/") {
+			return false
+		}
+		relPath = strings.TrimPrefix(relPath, p.base+"/")
+	}
+
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// Matcher evaluates ignore rules for paths inside an fs.FS, loading the
+// ignore files of each directory the first time a path below it is checked.
+type Matcher struct {
+	fsys   fs.FS
+	global []Pattern
+
+	// parents hold the patterns of the repository that contains the FS,
+	// which are matched against paths with prefix prepended.
+	prefix  string
+	parents []Pattern
+
+	mu      sync.Mutex
+	dirs    map[string][]Pattern
+	ignored map[string]bool
+}
+
+// NewMatcher creates a matcher for the repository at the root of fsys.
+// Defaults are gitignore patterns with the lowest precedence, followed by
+// .git/info/exclude and then the per-directory ignore files.
+func NewMatcher(fsys fs.FS, defaults []string) *Matcher {
+	m := &Matcher{
+		fsys:    fsys,
+		dirs:    make(map[string][]Pattern),
+		ignored: make(map[string]bool),
+	}
+
+	for _, line := range defaults {
+		if pattern, ok := parsePattern(line, ""); ok {
+			m.global = append(m.global, pattern)
+		}
+	}
+	if content, err := fs.ReadFile(fsys, ".git/info/exclude"); err == nil {
+		m.global = append(m.global, ParsePatterns(content, "")...)
+	}
+
+	return m
+}
+
+// AddParents adds patterns from outside the FS when its root is the
+// directory prefix of a larger repository, such as the .gitignore files of
+// the directories above it. They take precedence over the defaults and
+// .git/info/exclude, and are overridden by the ignore files inside the FS.
+func (m *Matcher) AddParents(prefix string, patterns []Pattern) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prefix = prefix
+	m.parents = append(m.parents, patterns...)
+	clear(m.ignored)
+}
+
+// ReadParents returns the patterns of the ignore files in the directories
+// containing dir, from the root of fsys down, without those of dir itself.
+func ReadParents(fsys fs.FS, dir string) []Pattern {
+	var patterns []Pattern
+	parent := ""
+	for _, part := range strings.Split(dir, "/") {
+		for _, name := range Files {
+			if content, err := fs.ReadFile(fsys, path.Join(parent, name)); err == nil {
+				patterns = append(patterns, ParsePatterns(content, parent)...)
+			}
+		}
+		parent = path.Join(parent, part)
+	}
+	return patterns
+}
+
+// Ignored reports whether relPath, or any directory containing it, is
+// excluded. relPath is slash-separated and relative to the root of the FS.
+func (m *Matcher) Ignored(relPath string, isDir bool) bool {
+	relPath = strings.Trim(path.Clean(relPath), "/")
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent := path.Dir(relPath)
+	if parent != "." && m.ignoredDir(parent) {
+		return true
+	}
+	return m.match(relPath, isDir)
+}
+
+func (m *Matcher) ignoredDir(dir string) bool {
+	if ignored, cached := m.ignored[dir]; cached {
+		return ignored
+	}
+
+	ignored := false
+	if parent := path.Dir(dir); parent != "." && m.ignoredDir(parent) {
+		ignored = true
+	} else {
+		ignored = m.match(dir, true)
+	}
+
+	m.ignored[dir] = ignored
+	return ignored
+}
+
+func (m *Matcher) match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range m.global {
+		if pattern.Match(relPath, isDir) {
+			ignored = !pattern.negate
+		}
+	}
+	for _, pattern := range m.parents {
+		if pattern.Match(m.prefix+"/"+relPath, isDir) {
+			ignored = !pattern.negate
+		}
+	}
+
+	// Patterns from deeper directories take precedence, and within a
+	// directory later patterns override earlier ones.
+	dirs := []string{""}
+	if parent := path.Dir(relPath); parent != "." {
+		parts := strings.Split(parent, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+	for _, dir := range dirs {
+		for _, pattern := range m.patternsFor(dir) {
+			if pattern.Match(relPath, isDir) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+
+	return ignored
+}
+
+func (m *Matcher) patternsFor(dir string) []Pattern {
+	if patterns, loaded := m.dirs[dir]; loaded {
+		return patterns
+	}
+
+	var patterns []Pattern
+	for _, name := range Files {
+		filePath := name
+		if dir != "" {
+			filePath = dir + "/" + name
+		}
+		if content, err := fs.ReadFile(m.fsys, filePath); err == nil {
+			patterns = append(patterns, ParsePatterns(content, dir)...)
+		}
+	}
+
+	m.dirs[dir] = patterns
+	return patterns
+}
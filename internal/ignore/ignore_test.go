@@ -0,0 +1,150 @@
+package ignore
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		base     string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"name at any depth", "*.log", "", "logs/app/debug.log", false, true},
+		{"name does not match partial", "build", "", "src/build-tools", true, false},
+		{"directory name at any depth", "out/", "", "packages/web/out", true, true},
+		{"directory only pattern skips files", "out/", "", "packages/web/out", false, false},
+		{"anchored to root", "/generated", "", "generated", true, true},
+		{"anchored does not match nested", "/generated", "", "src/generated", true, false},
+		{"middle slash anchors", "docs/*.md", "", "docs/intro.md", false, true},
+		{"middle slash anchors nested", "docs/*.md", "", "site/docs/intro.md", false, false},
+		{"wildcard does not cross directories", "docs/*.md", "", "docs/api/intro.md", false, false},
+		{"leading double star", "**/fixtures", "", "a/b/fixtures", true, true},
+		{"inner double star", "a/**/z", "", "a/b/c/z", false, true},
+		{"trailing double star", "vendor/**", "", "vendor/lib/x.go", false, true},
+		{"relative to nested base", "/cache", "services/api", "services/api/cache", true, true},
+		{"outside nested base", "cache", "services/api", "services/web/cache", true, false},
+		{"escaped hash", `\#notes`, "", "#notes", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns := ParsePatterns([]byte(tt.pattern), tt.base)
+			if len(patterns) != 1 {
+				t.Fatalf("Expected 1 pattern, got %d", len(patterns))
+			}
+			if result := patterns[0].Match(tt.path, tt.isDir); result != tt.expected {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePatternsSkipsCommentsAndBlankLines(t *testing.T) {
+	patterns := ParsePatterns([]byte("# comment\n\n*.tmp   \r\n!keep.tmp\n"), "")
+	if len(patterns) != 2 {
+		t.Fatalf("Expected 2 patterns, got %d", len(patterns))
+	}
+	if !patterns[1].negate {
+		t.Error("Expected second pattern to be a negation")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":               {Data: []byte("*.log\n/out/\n.turbo\n!important.log\n")},
+		".git/info/exclude":        {Data: []byte("scratch/\n")},
+		".replyzerignore":          {Data: []byte("generated/\n!.github/\n")},
+		"services/api/.gitignore":  {Data: []byte("/tmp\n!debug.log\n")},
+		"services/api/debug.log":   {Data: []byte("")},
+		"services/api/tmp/data":    {Data: []byte("")},
+		"services/web/tmp/data":    {Data: []byte("")},
+		"services/web/error.log":   {Data: []byte("")},
+		"out/bundle.js":            {Data: []byte("")},
+		"src/out/page.js":          {Data: []byte("")},
+		"src/build/main.go":        {Data: []byte("")},
+		"src/generated/models.go":  {Data: []byte("")},
+		"important.log":            {Data: []byte("")},
+		"scratch/notes.txt":        {Data: []byte("")},
+		".turbo/cache":             {Data: []byte("")},
+		".github/workflows/ci.yml": {Data: []byte("")},
+		"node_modules/x/index.js":  {Data: []byte("")},
+	}
+
+	matcher := NewMatcher(fsys, []string{".*/", "node_modules/"})
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"services/web/error.log", false, true},
+		{"important.log", false, false},
+		{"services/api/debug.log", false, false},
+		{"services/api/tmp", true, true},
+		{"services/api/tmp/data", false, true},
+		{"services/web/tmp/data", false, false},
+		{"out/bundle.js", false, true},
+		{"src/out/page.js", false, false},
+		{"src/build/main.go", false, false},
+		{"src/generated/models.go", false, true},
+		{"scratch/notes.txt", false, true},
+		{".turbo/cache", false, true},
+		{".github/workflows/ci.yml", false, false},
+		{"node_modules/x/index.js", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if result := matcher.Ignored(tt.path, tt.isDir); result != tt.expected {
+				t.Errorf("Ignored(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatcherParents(t *testing.T) {
+	repo := fstest.MapFS{
+		".gitignore":                   {Data: []byte("*.log\n/services/api/tmp/\ngenerated/\n")},
+		"services/.gitignore":          {Data: []byte("!keep.log\n")},
+		"services/api/.gitignore":      {Data: []byte("!generated/\n")},
+		"services/api/tmp/data":        {Data: []byte("")},
+		"services/api/keep.log":        {Data: []byte("")},
+		"services/api/error.log":       {Data: []byte("")},
+		"services/api/generated/a.go":  {Data: []byte("")},
+		"services/api/sub/generated/b": {Data: []byte("")},
+	}
+	fsys, err := fs.Sub(repo, "services/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher := NewMatcher(fsys, nil)
+	matcher.AddParents("services/api", ReadParents(repo, "services/api"))
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"tmp", true, true},
+		{"tmp/data", false, true},
+		{"error.log", false, true},
+		{"keep.log", false, false},
+		{"generated/a.go", false, false},
+		{"sub/generated/b", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if result := matcher.Ignored(tt.path, tt.isDir); result != tt.expected {
+				t.Errorf("Ignored(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
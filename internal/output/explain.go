@@ -0,0 +1,73 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// RenderExplanation lists the evidence for a finding of a component. The
+// component is looked up by path or name, the finding by name (e.g.
+// "PostgreSQL", "node") or kind (e.g. "framework"), ignoring case.
+func RenderExplanation(result *types.AnalysisResult, component, finding string) ([]byte, error) {
+	comp := findComponent(result, component)
+	if comp == nil {
+		return nil, fmt.Errorf("component %s not found", component)
+	}
+
+	var headings []string
+	lines := make(map[string][]string)
+	for _, evidence := range comp.Evidence {
+		if !strings.EqualFold(evidence.Name, finding) && !strings.EqualFold(evidence.Kind, finding) {
+			continue
+		}
+
+		heading := fmt.Sprintf("%s (%s)", evidence.Name, evidence.Kind)
+		if _, exists := lines[heading]; !exists {
+			headings = append(headings, heading)
+		}
+
+		location := evidence.File
+		if evidence.Line > 0 {
+			location = fmt.Sprintf("%s:%d", evidence.File, evidence.Line)
+		}
+		if location == "" {
+			lines[heading] = append(lines[heading], evidence.Match)
+		} else {
+			lines[heading] = append(lines[heading], location+"  "+evidence.Match)
+		}
+	}
+
+	var b strings.Builder
+	for _, heading := range headings {
+		fmt.Fprintf(&b, "%s in %s:\n", heading, comp.Name)
+		for _, line := range lines[heading] {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil, fmt.Errorf("no evidence for %s in component %s", finding, comp.Name)
+	}
+	return []byte(b.String()), nil
+}
+
+func findComponent(result *types.AnalysisResult, component string) *types.Component {
+	path := strings.TrimSuffix(strings.TrimPrefix(component, "./"), "/")
+	if path == "." {
+		path = ""
+	}
+
+	for i := range result.Components {
+		if result.Components[i].Path == path {
+			return &result.Components[i]
+		}
+	}
+	for i := range result.Components {
+		if result.Components[i].Name == component {
+			return &result.Components[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func TestRenderExplanation(t *testing.T) {
+	result := &types.AnalysisResult{
+		Components: []types.Component{
+			{Name: "shop", Path: ""},
+			{
+				Name: "api",
+				Path: "services/api",
+				Evidence: []types.Evidence{
+					{Kind: "database", Name: "PostgreSQL", File: "services/api/docker-compose.yml", Line: 3, Match: "postgres:16"},
+					{Kind: "database", Name: "Redis", File: "services/api/docker-compose.yml", Line: 5, Match: "redis:7"},
+					{Kind: "database", Name: "PostgreSQL", File: "services/api/.env.production", Line: 2, Match: "DATABASE_URL"},
+					{Kind: "framework", Name: "Gin", Match: "component override services/*"},
+				},
+			},
+		},
+	}
+
+	got, err := RenderExplanation(result, "./services/api/", "postgresql")
+	if err != nil {
+		t.Fatalf("RenderExplanation() error = %v", err)
+	}
+	want := `PostgreSQL (database) in api:
+  services/api/docker-compose.yml:3  postgres:16
+  services/api/.env.production:2  DATABASE_URL
+`
+	if string(got) != want {
+		t.Errorf("Unexpected explanation:\n%s", got)
+	}
+
+	got, err = RenderExplanation(result, "api", "framework")
+	if err != nil {
+		t.Fatalf("RenderExplanation() error = %v", err)
+	}
+	if string(got) != "Gin (framework) in api:\n  component override services/*\n" {
+		t.Errorf("Unexpected explanation:\n%s", got)
+	}
+
+	if _, err := RenderExplanation(result, ".", "node"); err == nil || !strings.Contains(err.Error(), "no evidence for node in component shop") {
+		t.Errorf("Expected a missing evidence error, got %v", err)
+	}
+	if _, err := RenderExplanation(result, "web", "node"); err == nil {
+		t.Error("Expected an error for an unknown component")
+	}
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+type graphNode struct {
+	path string
+	name string
+}
+
+func RenderGraph(result *types.AnalysisResult, format string) ([]byte, error) {
+	switch format {
+	case "dot":
+		return RenderDOT(result), nil
+	case "mermaid":
+		return RenderMermaid(result), nil
+	default:
+		return nil, fmt.Errorf("unsupported graph format: %s", format)
+	}
+}
+
+func RenderDOT(result *types.AnalysisResult) []byte {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range graphNodes(result) {
+		fmt.Fprintf(&b, "  %q [label=%q];\n", node.path, node.name)
+	}
+	for _, dep := range result.Dependencies {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", dep.From, dep.To, dep.Kind)
+	}
+
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func RenderMermaid(result *types.AnalysisResult) []byte {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	ids := make(map[string]string)
+	for i, node := range graphNodes(result) {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.path] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, mermaidEscape(node.name))
+	}
+	for _, dep := range result.Dependencies {
+		from, fromOK := ids[dep.From]
+		to, toOK := ids[dep.To]
+		if !fromOK || !toOK {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", from, mermaidEscape(dep.Kind), to)
+	}
+
+	return []byte(b.String())
+}
+
+func graphNodes(result *types.AnalysisResult) []graphNode {
+	nodes := make([]graphNode, 0, len(result.Components))
+	for _, comp := range result.Components {
+		nodePath := filepath.ToSlash(comp.Path)
+		if nodePath == "" {
+			nodePath = "."
+		}
+		nodes = append(nodes, graphNode{path: nodePath, name: comp.Name})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].path < nodes[j].path
+	})
+	return nodes
+}
+
+func mermaidEscape(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+func sampleGraphResult() *types.AnalysisResult {
+	return &types.AnalysisResult{
+		Components: []types.Component{
+			{Name: "web", Path: "apps/web"},
+			{Name: "ui", Path: "packages/ui"},
+			{Name: "repo", Path: ""},
+		},
+		Dependencies: []types.ComponentDependency{
+			{From: "apps/web", To: "packages/ui", Kind: "workspace"},
+		},
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	dot := string(RenderDOT(sampleGraphResult()))
+
+	expectedLines := []string{
+		"digraph dependencies {",
+		`  "." [label="repo"];`,
+		`  "apps/web" [label="web"];`,
+		`  "apps/web" -> "packages/ui" [label="workspace"];`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT output missing %q:\n%s", line, dot)
+		}
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	mermaid := string(RenderMermaid(sampleGraphResult()))
+
+	expected := "graph LR\n" +
+		"  n0[\"repo\"]\n" +
+		"  n1[\"web\"]\n" +
+		"  n2[\"ui\"]\n" +
+		"  n1 -->|workspace| n2\n"
+	if mermaid != expected {
+		t.Errorf("RenderMermaid() =\n%s\nwant\n%s", mermaid, expected)
+	}
+}
+
+func TestRenderGraphUnsupportedFormat(t *testing.T) {
+	if _, err := RenderGraph(sampleGraphResult(), "svg"); err == nil {
+		t.Error("Expected an error for unsupported graph format")
+	}
+}
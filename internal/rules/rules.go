@@ -0,0 +1,218 @@
+package rules
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default.yaml
+var defaultRules []byte
+
+const (
+	KindDatabase = "database"
+	KindService  = "service"
+)
+
+// Rule maps evidence found in a component to a framework, database, service
+// or tool name.
+type Rule struct {
+	Name string `yaml:"name"`
+	// Kind is "database" or "service" for service rules.
+	Kind string `yaml:"kind,omitempty"`
+	// Languages restricts framework rules to components whose primary
+	// language is one of these (case-insensitive).
+	Languages []string `yaml:"languages,omitempty"`
+	// Manifest lists file names or globs relative to the component
+	// directory. A single pattern can be given as a string.
+	Manifest Patterns `yaml:"manifest,omitempty"`
+	// Dependencies are package names declared in the manifest.
+	Dependencies []string `yaml:"dependencies,omitempty"`
+	// Contains are substrings of the manifest content.
+	Contains []string `yaml:"contains,omitempty"`
+	// Files match when any of them exists in the component directory.
+	Files []string `yaml:"files,omitempty"`
+	// Imports are modules imported by source files of framework components,
+	// including their submodules (e.g. "next" matches "next/link").
+	Imports []string `yaml:"imports,omitempty"`
+	// Images are substrings of docker-compose image names, without their
+	// registry, namespace and tag.
+	Images []string `yaml:"images,omitempty"`
+	// Env are substrings of .env lines.
+	Env []string `yaml:"env,omitempty"`
+	// EnvKeys restrict Env to lines whose variable name contains one of
+	// them, such as DATABASE_URL.
+	EnvKeys []string `yaml:"env_keys,omitempty"`
+}
+
+// Patterns is a list of file name patterns that can be written as a single
+// string in YAML.
+type Patterns []string
+
+func (p *Patterns) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = Patterns{node.Value}
+		return nil
+	}
+	var patterns []string
+	if err := node.Decode(&patterns); err != nil {
+		return err
+	}
+	*p = patterns
+	return nil
+}
+
+type RuleSet struct {
+	Frameworks []Rule `yaml:"frameworks"`
+	Services   []Rule `yaml:"services"`
+	Tools      []Rule `yaml:"tools"`
+}
+
+var (
+	defaultOnce sync.Once
+	defaultSet  *RuleSet
+)
+
+// Default returns a copy of the built-in rules.
+func Default() *RuleSet {
+	defaultOnce.Do(func() {
+		set, err := Parse(defaultRules)
+		if err != nil {
+			panic(fmt.Sprintf("rules: invalid built-in rules: %v", err))
+		}
+		defaultSet = set
+	})
+
+	return &RuleSet{
+		Frameworks: append([]Rule(nil), defaultSet.Frameworks...),
+		Services:   append([]Rule(nil), defaultSet.Services...),
+		Tools:      append([]Rule(nil), defaultSet.Tools...),
+	}
+}
+
+// Load returns the built-in rules extended with the rules from files, in
+// order.
+func Load(files ...string) (*RuleSet, error) {
+	set := Default()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules file %s: %w", file, err)
+		}
+
+		extra, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rules file %s: %w", file, err)
+		}
+		set.Merge(extra)
+	}
+	return set, nil
+}
+
+func Parse(data []byte) (*RuleSet, error) {
+	var set RuleSet
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&set); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// Merge adds the rules of other. Rules with the name of an existing rule in
+// the same section, and for services the same kind, replace it; other rules
+// are appended.
+func (s *RuleSet) Merge(other *RuleSet) {
+	s.Frameworks = mergeRules(s.Frameworks, other.Frameworks)
+	s.Services = mergeRules(s.Services, other.Services)
+	s.Tools = mergeRules(s.Tools, other.Tools)
+}
+
+func mergeRules(base, extra []Rule) []Rule {
+	for _, rule := range extra {
+		replaced := false
+		for i := range base {
+			if base[i].Name == rule.Name && base[i].Kind == rule.Kind {
+				base[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, rule)
+		}
+	}
+	return base
+}
+
+func (s *RuleSet) validate() error {
+	sections := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"frameworks", s.Frameworks},
+		{"services", s.Services},
+		{"tools", s.Tools},
+	}
+
+	for _, section := range sections {
+		for i, rule := range section.rules {
+			if rule.Name == "" {
+				return fmt.Errorf("%s rule %d has no name", section.name, i+1)
+			}
+			for _, manifest := range rule.Manifest {
+				if _, err := path.Match(manifest, ""); err != nil {
+					return fmt.Errorf("%s rule %s has an invalid manifest pattern: %w", section.name, rule.Name, err)
+				}
+			}
+
+			if len(rule.Imports) > 0 && section.name != "frameworks" {
+				return fmt.Errorf("%s rule %s: imports are only supported for frameworks", section.name, rule.Name)
+			}
+
+			switch section.name {
+			case "services":
+				if rule.Kind != KindDatabase && rule.Kind != KindService {
+					return fmt.Errorf("services rule %s must have kind %q or %q", rule.Name, KindDatabase, KindService)
+				}
+				if len(rule.Images) == 0 && len(rule.Env) == 0 {
+					return fmt.Errorf("services rule %s needs images or env patterns", rule.Name)
+				}
+				if len(rule.EnvKeys) > 0 && len(rule.Env) == 0 {
+					return fmt.Errorf("services rule %s has env_keys without env patterns", rule.Name)
+				}
+			default:
+				if len(rule.Files)+len(rule.Imports) == 0 && (len(rule.Manifest) == 0 || len(rule.Dependencies)+len(rule.Contains) == 0) {
+					return fmt.Errorf("%s rule %s needs files, imports, or a manifest with dependencies or contains", section.name, rule.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesLanguage reports whether a framework rule applies to a component
+// with the given primary language.
+func (r *Rule) MatchesLanguage(language string) bool {
+	if len(r.Languages) == 0 {
+		return true
+	}
+	for _, candidate := range r.Languages {
+		if strings.EqualFold(candidate, language) {
+			return true
+		}
+	}
+	return false
+}
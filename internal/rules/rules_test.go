@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	set := Default()
+	if len(set.Frameworks) == 0 || len(set.Services) == 0 || len(set.Tools) == 0 {
+		t.Fatalf("Expected built-in rules in every section, got %d/%d/%d",
+			len(set.Frameworks), len(set.Services), len(set.Tools))
+	}
+
+	set.Frameworks[0].Name = "Changed"
+	if Default().Frameworks[0].Name == "Changed" {
+		t.Error("Expected Default to return a copy of the built-in rules")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `frameworks:
+  - name: Svelte
+    languages: [JavaScript, TypeScript]
+    manifest: package.json
+    dependencies: [svelte]
+services:
+  - name: Redis
+    kind: service
+    images: [redis]
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	last := set.Frameworks[len(set.Frameworks)-1]
+	if last.Name != "Svelte" || !last.MatchesLanguage("typescript") || last.MatchesLanguage("Go") {
+		t.Errorf("Expected Svelte to be appended, got %+v", last)
+	}
+
+	var redis []Rule
+	for _, rule := range set.Services {
+		if rule.Name == "Redis" {
+			redis = append(redis, rule)
+		}
+	}
+	if len(redis) != 2 || redis[0].Kind != KindDatabase || redis[1].Kind != KindService {
+		t.Fatalf("Expected a Redis database and service rule, got %+v", redis)
+	}
+	if !reflect.DeepEqual(redis[1].Images, []string{"redis"}) || len(redis[1].Env) > 0 {
+		t.Errorf("Expected the Redis service rule to be replaced, got %+v", redis[1])
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing rules file")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", "frameworks:\n  - name: X\n    manifest: go.mod\n    contain: [x]\n", "field contain not found"},
+		{"missing name", "tools:\n  - files: [Makefile]\n", "tools rule 1 has no name"},
+		{"no evidence", "frameworks:\n  - name: X\n    manifest: go.mod\n", "needs files"},
+		{"bad kind", "services:\n  - name: X\n    kind: queue\n    images: [x]\n", "must have kind"},
+		{"no patterns", "services:\n  - name: X\n    kind: database\n", "needs images or env"},
+		{"env keys without env", "services:\n  - name: X\n    kind: database\n    images: [x]\n    env_keys: [DB_]\n", "env_keys without env"},
+		{"imports on tools", "tools:\n  - name: X\n    imports: [x]\n", "imports are only supported for frameworks"},
+		{"bad glob", "frameworks:\n  - name: X\n    manifest: \"[\"\n    contains: [x]\n", "invalid manifest pattern"},
+		{"bad glob in list", "frameworks:\n  - name: X\n    manifest: [go.mod, \"[\"]\n    contains: [x]\n", "invalid manifest pattern"},
+		{"bad manifest", "frameworks:\n  - name: X\n    manifest: {file: go.mod}\n    contains: [x]\n", "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse(nil); err != nil {
+		t.Errorf("Expected an empty rules file to be valid, got %v", err)
+	}
+}
+
+func TestManifestPatterns(t *testing.T) {
+	set, err := Parse([]byte("tools:\n  - name: A\n    manifest: go.mod\n    contains: [x]\n  - name: B\n    manifest: [Pipfile, \"requirements*.txt\"]\n    dependencies: [x]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := set.Tools[0].Manifest; !reflect.DeepEqual(got, Patterns{"go.mod"}) {
+		t.Errorf("Expected a single pattern, got %v", got)
+	}
+	if got := set.Tools[1].Manifest; !reflect.DeepEqual(got, Patterns{"Pipfile", "requirements*.txt"}) {
+		t.Errorf("Expected a list of patterns, got %v", got)
+	}
+}
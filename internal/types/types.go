@@ -1,26 +1,84 @@
 package types
 
+import "io"
+
 type AnalysisResult struct {
-	Repository Repository  `yaml:"repository" json:"repository"`
-	Components []Component `yaml:"components" json:"components"`
+	Repository   Repository            `yaml:"repository" json:"repository"`
+	Components   []Component           `yaml:"components" json:"components"`
+	Dependencies []ComponentDependency `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+	BuildOrder   []string              `yaml:"build_order,omitempty" json:"build_order,omitempty"`
 }
 
 type Repository struct {
-	Type string `yaml:"type" json:"type"` // "single" | "monorepo"
-	Path string `yaml:"path" json:"path"`
-	Name string `yaml:"name" json:"name"`
+	Type       string      `yaml:"type" json:"type"` // "single" | "monorepo"
+	Path       string      `yaml:"path" json:"path"`
+	Name       string      `yaml:"name" json:"name"`
+	Revision   string      `yaml:"revision,omitempty" json:"revision,omitempty"`
+	Commit     string      `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Workspaces []Workspace `yaml:"workspaces,omitempty" json:"workspaces,omitempty"`
+}
+
+type Workspace struct {
+	Tool    string   `yaml:"tool" json:"tool"` // "npm" | "yarn" | "pnpm" | "cargo" | "go" | "maven" | "gradle"
+	Path    string   `yaml:"path" json:"path"` // "." for the repository root
+	Members []string `yaml:"members" json:"members"`
 }
 
 type Component struct {
-	Name                 string                 `yaml:"name" json:"name"`
-	Path                 string                 `yaml:"path" json:"path"`
-	Type                 string                 `yaml:"type" json:"type"`
-	PrimaryLanguage      string                 `yaml:"primary_language" json:"primary_language"`
-	LanguageStats        map[string]float64     `yaml:"language_stats" json:"language_stats"`
-	Framework            string                 `yaml:"framework" json:"framework"`
-	VersionRequirements  map[string]string      `yaml:"version_requirements" json:"version_requirements"`
-	ExternalDependencies ExternalDependencies   `yaml:"external_dependencies" json:"external_dependencies"`
-	DevelopmentTools     []string               `yaml:"development_tools" json:"development_tools"`
+	Name                 string                   `yaml:"name" json:"name"`
+	Path                 string                   `yaml:"path" json:"path"`
+	Type                 string                   `yaml:"type" json:"type"`
+	PrimaryLanguage      string                   `yaml:"primary_language" json:"primary_language"`
+	LanguageStats        map[string]float64       `yaml:"language_stats" json:"language_stats"`
+	LanguageCounts       map[string]LanguageCount `yaml:"language_counts,omitempty" json:"language_counts,omitempty"`
+	Framework            string                   `yaml:"framework" json:"framework"`
+	FrameworkCandidates  []FrameworkCandidate     `yaml:"framework_candidates,omitempty" json:"framework_candidates,omitempty"`
+	VersionRequirements  map[string]string        `yaml:"version_requirements" json:"version_requirements"`
+	VersionConflicts     []VersionConflict        `yaml:"version_conflicts,omitempty" json:"version_conflicts,omitempty"`
+	ExternalDependencies ExternalDependencies     `yaml:"external_dependencies" json:"external_dependencies"`
+	DevelopmentTools     []string                 `yaml:"development_tools" json:"development_tools"`
+	Workspace            string                   `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	Evidence             []Evidence               `yaml:"evidence,omitempty" json:"evidence,omitempty"`
+}
+
+type LanguageCount struct {
+	Files    int `yaml:"files" json:"files"`
+	Bytes    int `yaml:"bytes" json:"bytes"`
+	Lines    int `yaml:"lines" json:"lines"`
+	Code     int `yaml:"code" json:"code"`
+	Comments int `yaml:"comments" json:"comments"`
+	Blank    int `yaml:"blank" json:"blank"`
+}
+
+type VersionConflict struct {
+	Key        string          `yaml:"key" json:"key"`
+	Value      string          `yaml:"value" json:"value"` // the winning value
+	File       string          `yaml:"file" json:"file"`
+	Overridden []VersionSource `yaml:"overridden" json:"overridden"` // in the order they were read
+}
+
+type VersionSource struct {
+	File  string `yaml:"file" json:"file"`
+	Value string `yaml:"value" json:"value"`
+}
+
+type FrameworkCandidate struct {
+	Name       string  `yaml:"name" json:"name"`
+	Confidence float64 `yaml:"confidence" json:"confidence"` // between 0 and 1
+}
+
+type Evidence struct {
+	Kind  string `yaml:"kind" json:"kind"`                     // "framework" | "version" | "database" | "service" | "tool"
+	Name  string `yaml:"name" json:"name"`                     // framework, database, service or tool name, or version key such as "node"
+	File  string `yaml:"file,omitempty" json:"file,omitempty"` // relative to the repository root, empty for overrides
+	Line  int    `yaml:"line,omitempty" json:"line,omitempty"`
+	Match string `yaml:"match" json:"match"` // matched text, or key for structured files
+}
+
+type ComponentDependency struct {
+	From string `yaml:"from" json:"from"` // component path, "." for the repository root
+	To   string `yaml:"to" json:"to"`
+	Kind string `yaml:"kind" json:"kind"` // "workspace" | "file" | "go-replace" | "cargo-path" | "maven-module" | "compose"
 }
 
 type ExternalDependencies struct {
@@ -29,21 +87,43 @@ type ExternalDependencies struct {
 }
 
 type AnalysisOptions struct {
-	Format    string
-	Output    string
-	Verbose   bool
-	Component string
-	Exclude   []string
+	Format      string
+	Output      string
+	Verbose     bool
+	Component   string
+	Exclude     []string
+	TrackedOnly bool
+	Revision    string
+
+	DisabledDetectors []string
+	RuleFiles         []string
+	Overrides         []ComponentOverride
+	Evidence          bool
+	StatsPrecision    *int      // nil for the default
+	Jobs              int       // 0 for one per CPU
+	StatsMode         string    // "bytes" (default) | "lines" | "files"
+	MaxFileSize       int64     // 0 for no limit
+	CacheDir          string    // empty disables the cache
+	LogOutput         io.Writer // os.Stderr if nil
+}
+
+type ComponentOverride struct {
+	Path      string `yaml:"path" json:"path"` // slash-separated glob relative to the repository root
+	Type      string `yaml:"type,omitempty" json:"type,omitempty"`
+	Framework string `yaml:"framework,omitempty" json:"framework,omitempty"`
 }
 
 type ProjectStructure struct {
 	Type       string
 	Components []ComponentInfo
+	Workspaces []Workspace
 }
 
 type ComponentInfo struct {
-	Name         string
-	Path         string
-	ConfigFiles  []string
-	RelativePath string
+	Name          string
+	Path          string
+	ConfigFiles   []string
+	RelativePath  string
+	Workspace     string // path of the workspace this component is a member of
+	WorkspaceRoot bool
 }
\ No newline at end of file
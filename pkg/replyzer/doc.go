@@ -0,0 +1,22 @@
+// Package replyzer analyzes source repositories to identify the languages,
+// frameworks, version requirements and external dependencies needed to
+// develop them.
+//
+//	analyzer := replyzer.New(replyzer.WithExclude("examples/*"))
+//	result, err := analyzer.Analyze(ctx, "/path/to/repo")
+//
+// # Compatibility
+//
+// This package follows semantic versioning. Within a major version:
+//
+//   - exported functions, options and methods keep their signatures;
+//   - fields of the result types (Result, Repository, Component and the
+//     types they reference) are never removed, renamed or retyped, and their
+//     YAML and JSON keys do not change;
+//   - new fields may be added to result types. Added fields are optional and
+//     omitted from YAML and JSON output when empty, so existing consumers of
+//     the serialized form keep working.
+//
+// Values reported by the analysis itself, such as detected framework names or
+// language percentages, may improve between minor versions.
+package replyzer
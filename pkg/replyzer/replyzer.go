@@ -0,0 +1,193 @@
+package replyzer
+
+import (
+	"context"
+	"io"
+	"io/fs"
+
+	"github.com/replyzer/analyze-repo/internal/analyzer"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// Analyzer analyzes repositories with a fixed set of options. It holds no
+// state between calls and is safe for concurrent use.
+type Analyzer struct {
+	options types.AnalysisOptions
+}
+
+// DefaultStatsPrecision is the number of decimal places language statistics
+// are rounded to unless WithStatsPrecision is given.
+const DefaultStatsPrecision = analyzer.DefaultStatsPrecision
+
+// DefaultMaxFileSize is the size in bytes above which files are left out of
+// language statistics unless WithMaxFileSize is given.
+const DefaultMaxFileSize = analyzer.DefaultMaxFileSize
+
+// Metrics language statistics can be based on, see WithStatsMode.
+const (
+	StatsModeBytes = analyzer.StatsModeBytes
+	StatsModeLines = analyzer.StatsModeLines
+	StatsModeFiles = analyzer.StatsModeFiles
+)
+
+// Option configures an Analyzer.
+type Option func(*Analyzer)
+
+// New returns an Analyzer configured with opts.
+func New(opts ...Option) *Analyzer {
+	a := &Analyzer{options: types.AnalysisOptions{
+		MaxFileSize: DefaultMaxFileSize,
+	}}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithComponent restricts the analysis to the component with the given name.
+func WithComponent(name string) Option {
+	return func(a *Analyzer) {
+		a.options.Component = name
+	}
+}
+
+// WithExclude skips components whose relative path matches any of the glob
+// patterns.
+func WithExclude(patterns ...string) Option {
+	return func(a *Analyzer) {
+		a.options.Exclude = append(a.options.Exclude, patterns...)
+	}
+}
+
+// WithTrackedOnly only analyzes files tracked in the git index.
+func WithTrackedOnly() Option {
+	return func(a *Analyzer) {
+		a.options.TrackedOnly = true
+	}
+}
+
+// WithRevision analyzes a git branch, tag or commit instead of the working
+// tree.
+func WithRevision(rev string) Option {
+	return func(a *Analyzer) {
+		a.options.Revision = rev
+	}
+}
+
+// WithDisabledDetectors turns off the named detectors, e.g. "env-files".
+// Unknown names make the analysis fail.
+func WithDisabledDetectors(names ...string) Option {
+	return func(a *Analyzer) {
+		a.options.DisabledDetectors = append(a.options.DisabledDetectors, names...)
+	}
+}
+
+// WithRules loads additional detection rules from YAML files. Rules named
+// like a built-in rule replace it.
+func WithRules(files ...string) Option {
+	return func(a *Analyzer) {
+		a.options.RuleFiles = append(a.options.RuleFiles, files...)
+	}
+}
+
+// WithOverrides forces the type or framework of matching components. Later
+// overrides take precedence over earlier ones.
+func WithOverrides(overrides ...ComponentOverride) Option {
+	return func(a *Analyzer) {
+		a.options.Overrides = append(a.options.Overrides, overrides...)
+	}
+}
+
+// WithEvidence keeps the evidence for every finding in the result.
+func WithEvidence() Option {
+	return func(a *Analyzer) {
+		a.options.Evidence = true
+	}
+}
+
+// WithStatsPrecision rounds language statistics to the given number of
+// decimal places instead of DefaultStatsPrecision.
+func WithStatsPrecision(decimals int) Option {
+	return func(a *Analyzer) {
+		a.options.StatsPrecision = &decimals
+	}
+}
+
+// WithStatsMode bases language statistics and the primary language on
+// StatsModeBytes (the default), StatsModeLines (lines of code) or
+// StatsModeFiles. Per-language counts of all metrics are always reported.
+func WithStatsMode(mode string) Option {
+	return func(a *Analyzer) {
+		a.options.StatsMode = mode
+	}
+}
+
+// WithMaxFileSize leaves files larger than the given number of bytes out of
+// language statistics instead of DefaultMaxFileSize; 0 removes the limit.
+// Binary, vendored, generated and documentation files are always left out.
+func WithMaxFileSize(bytes int64) Option {
+	return func(a *Analyzer) {
+		a.options.MaxFileSize = bytes
+	}
+}
+
+// WithJobs sets the number of components analyzed concurrently. The default,
+// 0, uses one worker per CPU. Results are in the same order either way.
+func WithJobs(n int) Option {
+	return func(a *Analyzer) {
+		a.options.Jobs = n
+	}
+}
+
+// WithCache caches per-file results of working trees in dir, so that
+// analyzing a repository again only re-processes the files that changed.
+// The cache is keyed by path, size, modification time and content hash.
+func WithCache(dir string) Option {
+	return func(a *Analyzer) {
+		a.options.CacheDir = dir
+	}
+}
+
+// WithVerbose writes progress information and warnings to standard error,
+// or to the writer set with WithLogOutput.
+func WithVerbose() Option {
+	return func(a *Analyzer) {
+		a.options.Verbose = true
+	}
+}
+
+// WithLogOutput sets where WithVerbose writes to instead of standard error.
+func WithLogOutput(w io.Writer) Option {
+	return func(a *Analyzer) {
+		a.options.LogOutput = w
+	}
+}
+
+// RegisterDetector adds a detector to every Analyzer. Detectors run in
+// registration order after the built-in ones. It panics if a detector with
+// the same name is already registered, so call it from an init function.
+// Components are analyzed concurrently, so detectors must be safe for
+// concurrent use.
+func RegisterDetector(detector Detector) {
+	analyzer.RegisterDetector(detector)
+}
+
+// DetectorNames returns the names of all registered detectors.
+func DetectorNames() []string {
+	return analyzer.DetectorNames()
+}
+
+// Analyze analyzes the repository directory or source archive (.tar.gz,
+// .tgz or .zip) at path.
+func (a *Analyzer) Analyze(ctx context.Context, path string) (*Result, error) {
+	options := a.options
+	return analyzer.AnalyzeRepository(ctx, path, &options)
+}
+
+// AnalyzeFS analyzes a repository rooted at fsys. name is reported as the
+// repository's path and name. Revisions and tracked-only analysis are not
+// available for file systems.
+func (a *Analyzer) AnalyzeFS(ctx context.Context, fsys fs.FS, name string) (*Result, error) {
+	options := a.options
+	return analyzer.AnalyzeFS(ctx, fsys, name, &options)
+}
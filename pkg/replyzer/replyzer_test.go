@@ -0,0 +1,131 @@
+package replyzer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/replyzer/analyze-repo/pkg/replyzer"
+)
+
+var monorepo = fstest.MapFS{
+	"web/package.json":     &fstest.MapFile{Data: []byte(`{"name": "web", "dependencies": {"vue": "^3.0.0"}}`)},
+	"web/src/main.js":      &fstest.MapFile{Data: []byte("import { createApp } from 'vue';\ncreateApp({}).mount('#app');\n")},
+	"worker/go.mod":        &fstest.MapFile{Data: []byte("module example.com/worker\n\ngo 1.22\n")},
+	"worker/main.go":       &fstest.MapFile{Data: []byte("package main\n\nfunc main() {\n\tprintln(\"working\")\n}\n")},
+	"examples/demo/go.mod": &fstest.MapFile{Data: []byte("module example.com/demo\n")},
+}
+
+func TestAnalyzeFS(t *testing.T) {
+	analyzer := replyzer.New(replyzer.WithExclude("examples/*"))
+
+	result, err := analyzer.AnalyzeFS(context.Background(), monorepo, "shop")
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+
+	var names []string
+	for _, comp := range result.Components {
+		names = append(names, comp.Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"web", "worker"}) {
+		t.Errorf("Expected components web and worker, got %v", names)
+	}
+
+	result, err = replyzer.New(replyzer.WithComponent("worker")).AnalyzeFS(context.Background(), monorepo, "shop")
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if len(result.Components) != 1 || result.Components[0].VersionRequirements["go"] != "1.22" {
+		t.Errorf("Expected only the worker component, got %+v", result.Components)
+	}
+}
+
+func TestVerboseOutput(t *testing.T) {
+	var log strings.Builder
+	_, err := replyzer.New(replyzer.WithVerbose(), replyzer.WithLogOutput(&log)).AnalyzeFS(context.Background(), monorepo, "shop")
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if !strings.Contains(log.String(), "Discovered 3 components\n") || !strings.Contains(log.String(), "Analyzing component: web\n") {
+		t.Errorf("Expected progress in the log output, got %q", log.String())
+	}
+
+	// Without a log output, progress goes to standard error so that it
+	// cannot corrupt results written to standard output.
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	_, err = replyzer.New(replyzer.WithVerbose()).AnalyzeFS(context.Background(), monorepo, "shop")
+	os.Stdout = stdout
+	writer.Close()
+	if err != nil {
+		t.Fatalf("AnalyzeFS() error = %v", err)
+	}
+	if output, _ := io.ReadAll(reader); len(output) > 0 {
+		t.Errorf("Expected nothing on standard output, got %q", output)
+	}
+}
+
+func TestAnalyzeFSCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := replyzer.New().AnalyzeFS(ctx, monorepo, "shop")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestResultKeysAreStable guards the serialized form of the result types,
+// which is covered by the package's compatibility promise.
+func TestResultKeysAreStable(t *testing.T) {
+	result := replyzer.Result{
+		Repository:   replyzer.Repository{Workspaces: []replyzer.Workspace{{}}},
+		Components:   []replyzer.Component{{}},
+		Dependencies: []replyzer.ComponentDependency{{}},
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Repository   map[string]json.RawMessage   `json:"repository"`
+		Components   []map[string]json.RawMessage `json:"components"`
+		Dependencies []map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	required := map[string][]string{
+		"repository": {"type", "path", "name", "workspaces"},
+		"component": {
+			"name", "path", "type", "primary_language", "language_stats", "framework",
+			"version_requirements", "external_dependencies", "development_tools",
+		},
+		"dependency": {"from", "to", "kind"},
+	}
+	check := func(kind string, fields map[string]json.RawMessage) {
+		for _, key := range required[kind] {
+			if _, exists := fields[key]; !exists {
+				t.Errorf("%s is missing the %q key", kind, key)
+			}
+		}
+	}
+	check("repository", decoded.Repository)
+	check("component", decoded.Components[0])
+	check("dependency", decoded.Dependencies[0])
+}
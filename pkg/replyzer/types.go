@@ -0,0 +1,53 @@
+package replyzer
+
+import (
+	"github.com/replyzer/analyze-repo/internal/analyzer"
+	"github.com/replyzer/analyze-repo/internal/types"
+)
+
+// Result is the outcome of analyzing a repository.
+type Result = types.AnalysisResult
+
+// Repository describes the analyzed repository as a whole.
+type Repository = types.Repository
+
+// Workspace is a package manager or build tool workspace and its members.
+type Workspace = types.Workspace
+
+// Component is a project inside the repository, identified by its
+// configuration files.
+type Component = types.Component
+
+// ComponentDependency is an edge of the dependency graph between components.
+type ComponentDependency = types.ComponentDependency
+
+// ExternalDependencies lists the databases and services a component needs.
+type ExternalDependencies = types.ExternalDependencies
+
+// ComponentInfo describes a discovered component before it is analyzed.
+type ComponentInfo = types.ComponentInfo
+
+// FrameworkCandidate is a framework detected in a component with its
+// confidence.
+type FrameworkCandidate = types.FrameworkCandidate
+
+// VersionConflict reports a version requirement set to different values by
+// several files, with the file that won.
+type VersionConflict = types.VersionConflict
+
+// VersionSource is a value of a version requirement and its file.
+type VersionSource = types.VersionSource
+
+// Evidence records the file, line and matched text a finding came from.
+type Evidence = types.Evidence
+
+// ComponentOverride forces the type or framework of the components whose
+// path matches a glob.
+type ComponentOverride = types.ComponentOverride
+
+// Detector inspects a component and records its findings on it. Register
+// custom detectors with RegisterDetector.
+type Detector = analyzer.Detector
+
+// Target is the component a Detector is applied to.
+type Target = analyzer.Target